@@ -2,40 +2,70 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"github.com/Suj8K/oxygen-go/services/login"
+	loginimpl "github.com/Suj8K/oxygen-go/services/login/impl"
 	"github.com/Suj8K/oxygen-go/services/sqlstore"
 	"github.com/Suj8K/oxygen-go/services/user"
 	"github.com/Suj8K/oxygen-go/services/user/impl"
+	"github.com/Suj8K/oxygen-go/setting"
 	"github.com/gorilla/mux"
 	"log"
 	"net/http"
+	"reflect"
 )
 
 type apiFunc func(w http.ResponseWriter, r *http.Request) error
 
-type apiFuncDB func(w http.ResponseWriter, r *http.Request, store *sqlstore.SQLStore) *user.User
-
-type APIError struct {
-	Error string
+// storageModels are the types API responses must not contain, they have to be mapped to a DTO,
+// e.g. with user.NewUserDTO, so that new columns are not exposed by accident.
+var storageModels = map[reflect.Type]bool{
+	reflect.TypeOf(user.User{}): true,
 }
 
+// WriteJSON writes v as the JSON body of the response. It refuses types containing a storage model,
+// the error is then answered as an internal error. Handlers return typed DTOs, so checking the type is enough.
 func WriteJSON(writer http.ResponseWriter, status int, v any) error {
-	writer.WriteHeader(status)
+	if v != nil {
+		if t := storageModelInType(reflect.TypeOf(v), make(map[reflect.Type]bool)); t != nil {
+			return fmt.Errorf("the response contains the storage model %s, it has to be mapped to a DTO", t)
+		}
+	}
 	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
 	return json.NewEncoder(writer).Encode(v)
 }
 
-func makeHttpHandlerFunc(f apiFunc) http.HandlerFunc {
-	return func(writer http.ResponseWriter, request *http.Request) {
-		if err := f(writer, request); err != nil {
-			WriteJSON(writer, http.StatusBadRequest, APIError{Error: err.Error()})
+// storageModelInType returns the storage model t contains, seen prevents looping on recursive types.
+func storageModelInType(t reflect.Type, seen map[reflect.Type]bool) reflect.Type {
+	if storageModels[t] {
+		return t
+	}
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return storageModelInType(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				if model := storageModelInType(t.Field(i).Type, seen); model != nil {
+					return model
+				}
+			}
 		}
 	}
+	return nil
 }
 
-func dbHttpHandlerFunc(f apiFuncDB, store *sqlstore.SQLStore) http.HandlerFunc {
+// makeHttpHandlerFunc returns the handler calling f, the errors of f are answered by translateError.
+func (s *APIServer) makeHttpHandlerFunc(f apiFunc) http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
-		if user := f(writer, request, store); user != nil {
-			WriteJSON(writer, http.StatusAccepted, user)
+		if err := f(writer, request); err != nil {
+			s.writeError(writer, request, err)
 		}
 	}
 }
@@ -43,22 +73,46 @@ func dbHttpHandlerFunc(f apiFuncDB, store *sqlstore.SQLStore) http.HandlerFunc {
 type APIServer struct {
 	listenAddr string
 	store      *sqlstore.SQLStore
+	users      user.Service
+	login      login.Service
 }
 
-func NewAPIServer(listenAddr string, store *sqlstore.SQLStore) *APIServer {
+func NewAPIServer(cfg *setting.Cfg, store *sqlstore.SQLStore) (*APIServer, error) {
+	users, err := impl.ProvideService(store)
+	if err != nil {
+		return nil, err
+	}
+	loginService, err := loginimpl.ProvideService(store, users, cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &APIServer{
-		listenAddr: listenAddr,
+		listenAddr: cfg.ListenAddr(),
 		store:      store,
-	}
+		users:      users,
+		login:      loginService,
+	}, nil
 }
 
-func (s APIServer) Run() {
+// Router returns the handler of every route of the API.
+func (s *APIServer) Router() http.Handler {
 	router := mux.NewRouter()
-	router.HandleFunc("/user/get", dbHttpHandlerFunc(impl.GetUser, s.store))
-	router.HandleFunc("/user/add", dbHttpHandlerFunc(impl.AddUserNew, s.store))
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusNotFound, APIError{Code: "not-found", Message: "no route matches " + r.URL.Path})
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusMethodNotAllowed, APIError{Code: "method-not-allowed", Message: r.Method + " is not allowed on " + r.URL.Path})
+	})
+	s.registerUserRoutes(router)
+	s.registerLoginRoutes(router)
+	return withRequestID(router)
+}
+
+func (s *APIServer) Run() {
 	log.Println("JSON API running on port: ", s.listenAddr)
 	log.Println("DB engine is: ", s.store.GetEngine().DriverName())
-	err := http.ListenAndServe(s.listenAddr, router)
+	err := http.ListenAndServe(s.listenAddr, s.Router())
 	if err != nil {
 		log.Println("Error while running server: ", err)
 	}
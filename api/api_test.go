@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Suj8K/oxygen-go/services/user"
+)
+
+func TestWriteJSONRefusesStorageModel(t *testing.T) {
+	type page struct {
+		Users []*user.User
+	}
+	refused := []interface{}{
+		user.User{},
+		&user.User{},
+		[]user.User{},
+		map[string]*user.User{},
+		page{},
+	}
+	for _, v := range refused {
+		rec := httptest.NewRecorder()
+		if err := WriteJSON(rec, http.StatusOK, v); err == nil {
+			t.Errorf("WriteJSON wrote %T, which contains user.User", v)
+		}
+	}
+
+	allowed := []interface{}{
+		user.NewUserDTO(&user.User{Login: "a"}),
+		&user.SearchUserQueryResult{},
+		APIError{Code: "internal"},
+		nil,
+	}
+	for _, v := range allowed {
+		rec := httptest.NewRecorder()
+		if err := WriteJSON(rec, http.StatusOK, v); err != nil {
+			t.Errorf("WriteJSON refused %T: %v", v, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/Suj8K/oxygen-go/services/login"
+	"github.com/Suj8K/oxygen-go/services/user"
+	"github.com/Suj8K/oxygen-go/util"
+)
+
+// requestIDHeader carries the id of a request, it is taken from the request when valid and always returned.
+const requestIDHeader = "X-Request-Id"
+
+// validRequestID matches the request ids taken from a request, any other is replaced, since the id is logged.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9-]{1,64}$`)
+
+// APIError is the body of every error response. Code is stable and meant for clients to test, Message is
+// meant for humans and may change.
+type APIError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// requestError is an error caused by the request itself, e.g. an invalid parameter or body.
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+// badRequest returns a requestError, it is answered with 400 and its message.
+func badRequest(format string, args ...interface{}) error {
+	return &requestError{err: fmt.Errorf(format, args...)}
+}
+
+// translateError returns the status and the body of the response to an error returned by a handler.
+// The message of an unknown error is only logged, the response refers to it by the id of the request.
+func (s *APIServer) translateError(r *http.Request, err error) (int, APIError) {
+	var reqErr *requestError
+	var conflictErr *user.ErrCaseInsensitiveLoginConflict
+	switch {
+	case errors.As(err, &reqErr):
+		return http.StatusBadRequest, APIError{Code: "bad-request", Message: reqErr.Error()}
+	case errors.Is(err, user.ErrUserNotFound):
+		return http.StatusNotFound, APIError{Code: "user-not-found", Message: "user not found"}
+	case errors.Is(err, user.ErrUserAlreadyExists):
+		return http.StatusConflict, APIError{Code: "user-already-exists", Message: "a user with this login or email already exists"}
+	case errors.As(err, &conflictErr):
+		// the error lists the conflicting users, which are not for the client to see
+		return http.StatusConflict, APIError{Code: "login-conflict", Message: "the login or email conflicts with other users when ignoring case"}
+	case errors.Is(err, user.ErrInvalidPassword):
+		return http.StatusBadRequest, APIError{Code: "invalid-old-password", Message: "the old password is invalid"}
+	case errors.Is(err, user.ErrPasswordNotSet):
+		return http.StatusUnprocessableEntity, APIError{Code: "password-not-set", Message: "the user has no password to change, e.g. a user of an external auth module"}
+	case errors.Is(err, user.ErrLastGrafanaAdmin):
+		return http.StatusUnprocessableEntity, APIError{Code: "last-admin", Message: "cannot remove the last server admin"}
+	case errors.Is(err, login.ErrInvalidCredentials):
+		return http.StatusUnauthorized, APIError{Code: "invalid-credentials", Message: "invalid username or password"}
+	case errors.Is(err, login.ErrSessionNotFound):
+		return http.StatusUnauthorized, APIError{Code: "unauthorized", Message: "a valid session is required, log in with POST /api/login"}
+	case errors.Is(err, login.ErrUserDisabled):
+		return http.StatusForbidden, APIError{Code: "user-disabled", Message: "user is disabled"}
+	case s.store.GetDialect().IsUniqueConstraintViolation(err):
+		return http.StatusConflict, APIError{Code: "unique-violation", Message: "the resource conflicts with an existing one"}
+	}
+
+	requestID := requestIDFromContext(r.Context())
+	log.Printf("request %s %s %s failed: %v", requestID, r.Method, r.URL.Path, err)
+	return http.StatusInternalServerError, APIError{
+		Code:    "internal",
+		Message: "internal server error",
+		Details: map[string]string{"requestId": requestID},
+	}
+}
+
+// writeError writes the response to an error returned by a handler.
+func (s *APIServer) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, body := s.translateError(r, err)
+	if err := WriteJSON(w, status, body); err != nil {
+		log.Printf("failed to write the error response of request %s: %v", requestIDFromContext(r.Context()), err)
+	}
+}
+
+type requestIDKey struct{}
+
+// withRequestID adds the id of the request to its context and to the response headers.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			var err error
+			if requestID, err = util.RandomHex(8); err != nil {
+				requestID = "unknown"
+			}
+		}
+		w.Header().Set(requestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, requestID)))
+	})
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
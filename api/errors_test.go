@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Suj8K/oxygen-go/services/login"
+	"github.com/Suj8K/oxygen-go/services/sqlstore"
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrations"
+	"github.com/Suj8K/oxygen-go/services/user"
+)
+
+func TestTranslateError(t *testing.T) {
+	ss := sqlstore.InitTestDB(t, &migrations.OxygenMigrations{})
+	s := &APIServer{store: ss}
+
+	insertUser := `INSERT INTO "user" (version, login, email, name, password, salt, rands, company, account_id, is_admin, created, updated) VALUES (0, 'a', 'a@x', '', '', '', '', '', 0, ?, ?, ?)`
+	if _, err := ss.GetEngine().Exec(insertUser, false, "2026-01-01 00:00:00", "2026-01-01 00:00:00"); err != nil {
+		t.Fatal(err)
+	}
+	_, uniqueErr := ss.GetEngine().Exec(insertUser, false, "2026-01-01 00:00:00", "2026-01-01 00:00:00")
+	if uniqueErr == nil {
+		t.Fatal("inserting the same login twice succeeded")
+	}
+
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{err: badRequest("invalid page %q", "x"), status: http.StatusBadRequest, code: "bad-request"},
+		{err: fmt.Errorf("get user: %w", user.ErrUserNotFound), status: http.StatusNotFound, code: "user-not-found"},
+		{err: fmt.Errorf("service account with login a: %w", user.ErrUserAlreadyExists), status: http.StatusConflict, code: "user-already-exists"},
+		{err: &user.ErrCaseInsensitiveLoginConflict{Users: []user.User{{Login: "a"}, {Login: "A"}}}, status: http.StatusConflict, code: "login-conflict"},
+		{err: user.ErrLastGrafanaAdmin, status: http.StatusUnprocessableEntity, code: "last-admin"},
+		{err: user.ErrInvalidPassword, status: http.StatusBadRequest, code: "invalid-old-password"},
+		{err: user.ErrPasswordNotSet, status: http.StatusUnprocessableEntity, code: "password-not-set"},
+		{err: login.ErrInvalidCredentials, status: http.StatusUnauthorized, code: "invalid-credentials"},
+		{err: login.ErrSessionNotFound, status: http.StatusUnauthorized, code: "unauthorized"},
+		{err: login.ErrUserDisabled, status: http.StatusForbidden, code: "user-disabled"},
+		{err: uniqueErr, status: http.StatusConflict, code: "unique-violation"},
+		{err: errors.New("connection refused"), status: http.StatusInternalServerError, code: "internal"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+		status, body := s.translateError(r, tt.err)
+		if status != tt.status || body.Code != tt.code {
+			t.Errorf("%v is answered with %d %s, expected %d %s", tt.err, status, body.Code, tt.status, tt.code)
+		}
+	}
+
+	// the message of an unknown error is not returned
+	_, body := s.translateError(httptest.NewRequest(http.MethodGet, "/api/users/1", nil), errors.New("password=secret"))
+	if strings.Contains(body.Message, "secret") || body.Details == nil {
+		t.Errorf("an unknown error is answered with %+v, expected a generic message and the request id", body)
+	}
+}
+
+func TestCreateServiceAccountConflict(t *testing.T) {
+	router := newTestRouter(t)
+	cookie := signUp(t, router, "admin")
+
+	for _, want := range []int{http.StatusCreated, http.StatusConflict} {
+		r := httptest.NewRequest(http.MethodPost, "/api/users/service-accounts", strings.NewReader(`{"login":"sa-1"}`))
+		r.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		if rec.Code != want {
+			t.Fatalf("creating the service account is answered with %d %s, expected %d", rec.Code, rec.Body, want)
+		}
+		if want == http.StatusConflict && !strings.Contains(rec.Body.String(), `"code":"user-already-exists"`) {
+			t.Errorf("the duplicate service account is answered with %s, expected user-already-exists", rec.Body)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	handler := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(requestIDFromContext(r.Context())))
+	}))
+
+	tests := []struct {
+		header string
+		kept   bool
+	}{
+		{header: "3f2a-77b1-client", kept: true},
+		{header: "", kept: false},
+		{header: "abc\nrequest 1 GET / failed: forged", kept: false},
+		{header: "abc def", kept: false},
+		{header: strings.Repeat("a", 65), kept: false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(requestIDHeader, tt.header)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		got := rec.Header().Get(requestIDHeader)
+		if got != rec.Body.String() {
+			t.Errorf("the response header %q differs from the id in the context %q", got, rec.Body)
+		}
+		if tt.kept && got != tt.header {
+			t.Errorf("request id %q is replaced by %q", tt.header, got)
+		}
+		if !tt.kept && (got == tt.header || !validRequestID.MatchString(got)) {
+			t.Errorf("request id %q is replaced by %q, expected a generated id", tt.header, got)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Suj8K/oxygen-go/services/login"
+	"github.com/gorilla/mux"
+)
+
+// sessionCookie is the cookie carrying the session token, for clients not sending it themselves
+const sessionCookie = "oxygen_session"
+
+// requireSession returns a middleware rejecting the requests that change data without the cookie of a valid session.
+// GET requests and the public routes, e.g. the sign-up, are served without one.
+func (s *APIServer) requireSession(public ...*mux.Route) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead || isRoute(mux.CurrentRoute(r), public) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cookie, err := r.Cookie(sessionCookie)
+			if err != nil {
+				s.writeError(w, r, login.ErrSessionNotFound)
+				return
+			}
+			if _, err := s.login.GetSession(r.Context(), cookie.Value); err != nil {
+				s.writeError(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isRoute(route *mux.Route, routes []*mux.Route) bool {
+	for _, r := range routes {
+		if r == route {
+			return true
+		}
+	}
+	return false
+}
+
+// registerLoginRoutes registers the routes of the login service.
+func (s *APIServer) registerLoginRoutes(router *mux.Router) {
+	router.HandleFunc("/api/login", s.makeHttpHandlerFunc(s.loginUser)).Methods(http.MethodPost)
+}
+
+// POST /api/login with {"user": "login or email", "password": ""}
+func (s *APIServer) loginUser(w http.ResponseWriter, r *http.Request) error {
+	var cmd login.LoginCommand
+	if err := decodeJSON(r, &cmd); err != nil {
+		return err
+	}
+	if cmd.User == "" || cmd.Password == "" {
+		return badRequest("the user and password are required")
+	}
+
+	session, err := s.login.Login(r.Context(), &cmd)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    session.Token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return WriteJSON(w, http.StatusOK, login.NewSessionDTO(session))
+}
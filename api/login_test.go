@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Suj8K/oxygen-go/services/sqlstore"
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrations"
+	"github.com/Suj8K/oxygen-go/setting"
+)
+
+// newTestRouter returns the router of an API server on a migrated test database.
+func newTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+	ss := sqlstore.InitTestDB(t, &migrations.OxygenMigrations{}, migrations.ProvideLoginMigrations())
+	cfg := setting.NewCfg()
+	cfg.LoginMaxLifetime = time.Hour
+	s, err := NewAPIServer(cfg, ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Router()
+}
+
+// signUp creates a user with the login and the password pw, logs it in and returns its session cookie.
+func signUp(t *testing.T, router http.Handler, login string) *http.Cookie {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader(`{"login":"`+login+`","email":"`+login+`@x","password":"pw"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("the sign-up of %s is answered with %d %s", login, rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"user":"`+login+`","password":"pw"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("the login of %s is answered with %d %s", login, rec.Code, rec.Body)
+	}
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == sessionCookie {
+			return cookie
+		}
+	}
+	t.Fatalf("the login of %s does not set the %s cookie", login, sessionCookie)
+	return nil
+}
+
+func TestRequireSession(t *testing.T) {
+	router := newTestRouter(t)
+	cookie := signUp(t, router, "admin")
+
+	serve := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		return rec
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		cookie *http.Cookie
+		status int
+	}{
+		{name: "read without session", method: http.MethodGet, path: "/api/users/1", status: http.StatusOK},
+		{name: "change without session", method: http.MethodPut, path: "/api/users/1/helpflags", status: http.StatusUnauthorized},
+		{name: "change with an unknown token", method: http.MethodPut, path: "/api/users/1/helpflags", cookie: &http.Cookie{Name: sessionCookie, Value: "unknown"}, status: http.StatusUnauthorized},
+		{name: "change with the session", method: http.MethodPut, path: "/api/users/1/helpflags", cookie: cookie, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rec := serve(tt.method, tt.path, `{"helpFlags1":1}`, tt.cookie); rec.Code != tt.status {
+			t.Errorf("%s is answered with %d %s, expected %d", tt.name, rec.Code, rec.Body, tt.status)
+		}
+	}
+
+	// the sessions of a disabled user are rejected
+	if rec := serve(http.MethodPost, "/api/users/1/disable", "", cookie); rec.Code != http.StatusNoContent {
+		t.Fatalf("disabling the user is answered with %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(http.MethodPut, "/api/users/1/helpflags", `{"helpFlags1":2}`, cookie); rec.Code != http.StatusForbidden {
+		t.Errorf("the session of a disabled user is answered with %d %s, expected %d", rec.Code, rec.Body, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Suj8K/oxygen-go/services/user"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultSearchPerPage is the page size of a user search without perpage parameter
+	defaultSearchPerPage = 1000
+	// maxSearchPerPage bounds the perpage parameter, so that a search cannot load every user at once
+	maxSearchPerPage = 1000
+)
+
+// registerUserRoutes registers the routes of the user service under /api/users.
+func (s *APIServer) registerUserRoutes(router *mux.Router) {
+	r := router.PathPrefix("/api/users").Subrouter()
+	signUp := r.HandleFunc("", s.makeHttpHandlerFunc(s.createUser)).Methods(http.MethodPost)
+	r.HandleFunc("/service-accounts", s.makeHttpHandlerFunc(s.createServiceAccount)).Methods(http.MethodPost)
+	r.HandleFunc("/search", s.makeHttpHandlerFunc(s.searchUsers)).Methods(http.MethodGet)
+	r.HandleFunc("/lookup", s.makeHttpHandlerFunc(s.lookupUser)).Methods(http.MethodGet)
+	r.HandleFunc("/disable", s.makeHttpHandlerFunc(s.batchDisableUsers)).Methods(http.MethodPost)
+	r.HandleFunc("/{id:[0-9]+}", s.makeHttpHandlerFunc(s.getUser)).Methods(http.MethodGet)
+	r.HandleFunc("/{id:[0-9]+}", s.makeHttpHandlerFunc(s.updateUser)).Methods(http.MethodPut)
+	r.HandleFunc("/{id:[0-9]+}", s.makeHttpHandlerFunc(s.deleteUser)).Methods(http.MethodDelete)
+	r.HandleFunc("/{id:[0-9]+}/profile", s.makeHttpHandlerFunc(s.getUserProfile)).Methods(http.MethodGet)
+	r.HandleFunc("/{id:[0-9]+}/disable", s.makeHttpHandlerFunc(s.disableUser(true))).Methods(http.MethodPost)
+	r.HandleFunc("/{id:[0-9]+}/enable", s.makeHttpHandlerFunc(s.disableUser(false))).Methods(http.MethodPost)
+	r.HandleFunc("/{id:[0-9]+}/password", s.makeHttpHandlerFunc(s.changeUserPassword)).Methods(http.MethodPut)
+	r.HandleFunc("/{id:[0-9]+}/permissions", s.makeHttpHandlerFunc(s.updateUserPermissions)).Methods(http.MethodPut)
+	r.HandleFunc("/{id:[0-9]+}/helpflags", s.makeHttpHandlerFunc(s.setUserHelpFlags)).Methods(http.MethodPut)
+	// the first user has to sign up without a session
+	r.Use(s.requireSession(signUp))
+}
+
+// POST /api/users
+func (s *APIServer) createUser(w http.ResponseWriter, r *http.Request) error {
+	var cmd user.CreateUserCommand
+	if err := decodeJSON(r, &cmd); err != nil {
+		return err
+	}
+	usr, err := s.users.Create(r.Context(), &cmd)
+	if err != nil {
+		return err
+	}
+	return WriteJSON(w, http.StatusCreated, user.NewUserDTO(usr))
+}
+
+// POST /api/users/service-accounts
+func (s *APIServer) createServiceAccount(w http.ResponseWriter, r *http.Request) error {
+	var cmd user.CreateUserCommand
+	if err := decodeJSON(r, &cmd); err != nil {
+		return err
+	}
+	usr, err := s.users.CreateServiceAccount(r.Context(), &cmd)
+	if err != nil {
+		return err
+	}
+	return WriteJSON(w, http.StatusCreated, user.NewUserDTO(usr))
+}
+
+// GET /api/users/:id
+func (s *APIServer) getUser(w http.ResponseWriter, r *http.Request) error {
+	usr, err := s.users.GetByID(r.Context(), &user.GetUserByIDQuery{ID: pathID(r)})
+	if err != nil {
+		return err
+	}
+	return WriteJSON(w, http.StatusOK, user.NewUserDTO(usr))
+}
+
+// GET /api/users/lookup?loginOrEmail=, or ?email= to only match the email
+func (s *APIServer) lookupUser(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	var usr *user.User
+	var err error
+	switch {
+	case query.Get("loginOrEmail") != "":
+		usr, err = s.users.GetByLogin(r.Context(), &user.GetUserByLoginQuery{LoginOrEmail: query.Get("loginOrEmail")})
+	case query.Get("email") != "":
+		usr, err = s.users.GetByEmail(r.Context(), &user.GetUserByEmailQuery{Email: query.Get("email")})
+	default:
+		return badRequest("the loginOrEmail or email parameter is required")
+	}
+	if err != nil {
+		return err
+	}
+	return WriteJSON(w, http.StatusOK, user.NewUserDTO(usr))
+}
+
+// GET /api/users/:id/profile
+func (s *APIServer) getUserProfile(w http.ResponseWriter, r *http.Request) error {
+	profile, err := s.users.GetProfile(r.Context(), &user.GetUserProfileQuery{UserID: pathID(r)})
+	if err != nil {
+		return err
+	}
+	return WriteJSON(w, http.StatusOK, profile)
+}
+
+// PUT /api/users/:id
+func (s *APIServer) updateUser(w http.ResponseWriter, r *http.Request) error {
+	var cmd user.UpdateUserCommand
+	if err := decodeJSON(r, &cmd); err != nil {
+		return err
+	}
+	cmd.UserID = pathID(r)
+	if err := s.users.Update(r.Context(), &cmd); err != nil {
+		return err
+	}
+
+	usr, err := s.users.GetByID(r.Context(), &user.GetUserByIDQuery{ID: cmd.UserID})
+	if err != nil {
+		return err
+	}
+	return WriteJSON(w, http.StatusOK, user.NewUserDTO(usr))
+}
+
+// DELETE /api/users/:id
+func (s *APIServer) deleteUser(w http.ResponseWriter, r *http.Request) error {
+	if err := s.users.Delete(r.Context(), &user.DeleteUserCommand{UserID: pathID(r)}); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// GET /api/users/search?query=&page=&perpage=&isDisabled=
+func (s *APIServer) searchUsers(w http.ResponseWriter, r *http.Request) error {
+	params := r.URL.Query()
+	query := &user.SearchUsersQuery{
+		Query:      params.Get("query"),
+		AuthModule: params.Get("authModule"),
+		Page:       1,
+		Limit:      defaultSearchPerPage,
+	}
+
+	var err error
+	if v := params.Get("page"); v != "" {
+		if query.Page, err = strconv.Atoi(v); err != nil || query.Page < 1 {
+			return badRequest("invalid page %q", v)
+		}
+	}
+	if v := params.Get("perpage"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil || query.Limit < 1 {
+			return badRequest("invalid perpage %q", v)
+		}
+		if query.Limit > maxSearchPerPage {
+			return badRequest("perpage %d exceeds the maximum of %d", query.Limit, maxSearchPerPage)
+		}
+	}
+	if v := params.Get("isDisabled"); v != "" {
+		isDisabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return badRequest("invalid isDisabled %q", v)
+		}
+		query.IsDisabled = &isDisabled
+	}
+
+	result, err := s.users.Search(r.Context(), query)
+	if err != nil {
+		return err
+	}
+	result.Page = query.Page
+	result.PerPage = query.Limit
+	return WriteJSON(w, http.StatusOK, result)
+}
+
+// POST /api/users/:id/disable and POST /api/users/:id/enable
+func (s *APIServer) disableUser(isDisabled bool) apiFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		cmd := &user.DisableUserCommand{UserID: pathID(r), IsDisabled: isDisabled}
+		if err := s.users.Disable(r.Context(), cmd); err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// POST /api/users/disable with {"userIds": [], "isDisabled": true}
+func (s *APIServer) batchDisableUsers(w http.ResponseWriter, r *http.Request) error {
+	var cmd user.BatchDisableUsersCommand
+	if err := decodeJSON(r, &cmd); err != nil {
+		return err
+	}
+	if err := s.users.BatchDisableUsers(r.Context(), &cmd); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// PUT /api/users/:id/password, the old password has to match
+func (s *APIServer) changeUserPassword(w http.ResponseWriter, r *http.Request) error {
+	var cmd user.ChangeUserPasswordCommand
+	if err := decodeJSON(r, &cmd); err != nil {
+		return err
+	}
+	cmd.UserID = pathID(r)
+	if cmd.NewPassword == "" {
+		return badRequest("the new password is required")
+	}
+	if err := s.users.ChangePassword(r.Context(), &cmd); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// PUT /api/users/:id/permissions with {"isGrafanaAdmin": true}
+func (s *APIServer) updateUserPermissions(w http.ResponseWriter, r *http.Request) error {
+	var body struct {
+		IsGrafanaAdmin bool `json:"isGrafanaAdmin"`
+	}
+	if err := decodeJSON(r, &body); err != nil {
+		return err
+	}
+	if err := s.users.UpdatePermissions(r.Context(), pathID(r), body.IsGrafanaAdmin); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// PUT /api/users/:id/helpflags with {"helpFlags1": 1}
+func (s *APIServer) setUserHelpFlags(w http.ResponseWriter, r *http.Request) error {
+	var cmd user.SetUserHelpFlagCommand
+	if err := decodeJSON(r, &cmd); err != nil {
+		return err
+	}
+	cmd.UserID = pathID(r)
+	if err := s.users.SetUserHelpFlag(r.Context(), &cmd); err != nil {
+		return err
+	}
+	return WriteJSON(w, http.StatusOK, map[string]user.HelpFlags1{"helpFlags1": cmd.HelpFlags1})
+}
+
+// decodeJSON decodes the JSON body of the request into v.
+func decodeJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return badRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+// pathID returns the id of the route, the route pattern only matches digits.
+func pathID(r *http.Request) int64 {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	return id
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSearchUsersPerPage(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		perpage string
+		status  int
+	}{
+		{perpage: "1", status: http.StatusOK},
+		{perpage: "1000", status: http.StatusOK},
+		{perpage: "1001", status: http.StatusBadRequest},
+		{perpage: "0", status: http.StatusBadRequest},
+		{perpage: "x", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/search?perpage="+tt.perpage, nil))
+		if rec.Code != tt.status {
+			t.Errorf("perpage=%s is answered with %d %s, expected %d", tt.perpage, rec.Code, rec.Body, tt.status)
+		}
+	}
+}
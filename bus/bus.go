@@ -0,0 +1,155 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// HandlerFunc is a function of the form func(context.Context, *events.X) error.
+type HandlerFunc interface{}
+
+// Msg is an event published on the bus, always a pointer to a struct.
+type Msg interface{}
+
+// Mode controls how a handler is called when an event is published.
+type Mode int
+
+const (
+	// Sync handlers are called in the publishing goroutine, the first error stops
+	// the delivery and is returned to the publisher.
+	Sync Mode = iota
+	// Async handlers are called in their own goroutine, errors are only logged.
+	Async
+)
+
+// Bus type defines the bus interface structure
+type Bus interface {
+	Publish(ctx context.Context, msg Msg) error
+	Subscribe(handler HandlerFunc)
+	SubscribeAsync(handler HandlerFunc)
+}
+
+type listener struct {
+	handler reflect.Value
+	mode    Mode
+}
+
+// InProcBus defines the bus structure
+type InProcBus struct {
+	mu        sync.RWMutex
+	listeners map[reflect.Type][]listener
+	inflight  sync.WaitGroup
+}
+
+func ProvideBus() *InProcBus {
+	return &InProcBus{
+		listeners: make(map[reflect.Type][]listener),
+	}
+}
+
+// Subscribe registers a handler called synchronously for every published event of its argument type.
+func (b *InProcBus) Subscribe(handler HandlerFunc) {
+	b.subscribe(handler, Sync)
+}
+
+// SubscribeAsync registers a handler called in a separate goroutine for every published event of its argument type.
+func (b *InProcBus) SubscribeAsync(handler HandlerFunc) {
+	b.subscribe(handler, Async)
+}
+
+func (b *InProcBus) subscribe(handler HandlerFunc, mode Mode) {
+	msgType, err := handlerMsgType(handler)
+	if err != nil {
+		panic(err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners[msgType] = append(b.listeners[msgType], listener{handler: reflect.ValueOf(handler), mode: mode})
+}
+
+// Publish delivers the message to every handler subscribed to its type.
+func (b *InProcBus) Publish(ctx context.Context, msg Msg) error {
+	b.mu.RLock()
+	listeners := b.listeners[reflect.TypeOf(msg)]
+	b.mu.RUnlock()
+
+	params := []reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(msg)}
+	for _, l := range listeners {
+		if l.mode == Async {
+			b.callAsync(ctx, l, msg)
+			continue
+		}
+
+		if err := call(l.handler, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Wait blocks until the async handlers of the events published so far have returned.
+func (b *InProcBus) Wait() {
+	b.inflight.Wait()
+}
+
+func (b *InProcBus) callAsync(ctx context.Context, l listener, msg Msg) {
+	// async handlers outlive the publisher, they keep the context values but not its cancellation
+	params := []reflect.Value{reflect.ValueOf(detachedContext{parent: ctx}), reflect.ValueOf(msg)}
+
+	b.inflight.Add(1)
+	go func() {
+		defer b.inflight.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("bus: async handler for %T panicked: %v", msg, r)
+			}
+		}()
+
+		if err := call(l.handler, params); err != nil {
+			log.Printf("bus: async handler for %T failed: %v", msg, err)
+		}
+	}()
+}
+
+func call(handler reflect.Value, params []reflect.Value) error {
+	ret := handler.Call(params)
+	if e := ret[0].Interface(); e != nil {
+		return e.(error)
+	}
+	return nil
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+func handlerMsgType(handler HandlerFunc) (reflect.Type, error) {
+	handlerType := reflect.TypeOf(handler)
+	if handlerType == nil || handlerType.Kind() != reflect.Func {
+		return nil, fmt.Errorf("bus handler must be a function, got %T", handler)
+	}
+	if handlerType.NumIn() != 2 || handlerType.In(0) != contextType ||
+		handlerType.NumOut() != 1 || handlerType.Out(0) != errorType {
+		return nil, fmt.Errorf("bus handler must be of the form func(context.Context, *Event) error, got %s", handlerType)
+	}
+	msgType := handlerType.In(1)
+	if msgType.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("bus handler must take a pointer to the event, got %s", msgType)
+	}
+	return msgType, nil
+}
+
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (c detachedContext) Done() <-chan struct{}       { return nil }
+func (c detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any           { return c.parent.Value(key) }
@@ -0,0 +1,83 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+type testEvent struct {
+	Name string
+}
+
+func TestPublish(t *testing.T) {
+	b := ProvideBus()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var syncCalls, asyncCalls int32
+	b.Subscribe(func(ctx context.Context, e *testEvent) error {
+		atomic.AddInt32(&syncCalls, 1)
+		return nil
+	})
+	b.SubscribeAsync(func(ctx context.Context, e *testEvent) error {
+		if ctx.Err() != nil {
+			t.Errorf("the async handler got a cancelled context: %v", ctx.Err())
+		}
+		atomic.AddInt32(&asyncCalls, 1)
+		return errors.New("only logged")
+	})
+
+	if err := b.Publish(ctx, &testEvent{Name: "a"}); err != nil {
+		t.Fatalf("Publish returned %v, the error of an async handler is only logged", err)
+	}
+	cancel()
+	b.Wait()
+	if syncCalls != 1 || asyncCalls != 1 {
+		t.Errorf("the handlers were called %d and %d times, expected once each", syncCalls, asyncCalls)
+	}
+
+	// an event without handlers is fine
+	if err := b.Publish(context.Background(), &struct{}{}); err != nil {
+		t.Errorf("publishing an event without handlers returned %v", err)
+	}
+}
+
+func TestPublishReturnsSyncError(t *testing.T) {
+	b := ProvideBus()
+	failed := errors.New("failed")
+	var after bool
+	b.Subscribe(func(ctx context.Context, e *testEvent) error {
+		return failed
+	})
+	b.Subscribe(func(ctx context.Context, e *testEvent) error {
+		after = true
+		return nil
+	})
+
+	if err := b.Publish(context.Background(), &testEvent{}); !errors.Is(err, failed) {
+		t.Errorf("Publish returned %v, expected the error of the sync handler", err)
+	}
+	if after {
+		t.Errorf("the handler after the failing one was called")
+	}
+}
+
+func TestSubscribeRejectsInvalidHandlers(t *testing.T) {
+	handlers := []HandlerFunc{
+		nil,
+		func(e *testEvent) error { return nil },
+		func(ctx context.Context, e testEvent) error { return nil },
+		func(ctx context.Context, e *testEvent) {},
+	}
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Subscribe accepted the handler %T", handler)
+				}
+			}()
+			ProvideBus().Subscribe(handler)
+		}()
+	}
+}
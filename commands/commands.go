@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/Suj8K/oxygen-go/bus"
+	"github.com/Suj8K/oxygen-go/services/login"
+	"github.com/Suj8K/oxygen-go/services/sqlstore"
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrations"
+	"github.com/Suj8K/oxygen-go/services/user"
+	"github.com/Suj8K/oxygen-go/setting"
+)
+
+// ErrUsage is returned when the command line is invalid, the usage has already been printed then.
+var ErrUsage = errors.New("invalid usage")
+
+const usageText = `Usage: oxygen [-config path] [-homepath path] <command> [flags]
+
+Commands:
+  serve                  run the migrations and start the HTTP server (default)
+  migrate up             run the pending migrations
+  migrate status         list the applied, pending and failed migrations
+  migrate plan           show the SQL the pending migrations would run, without running it
+  migrate verify         report applied migrations that were changed or removed from the code
+  migrate diff           compare the database schema with the migrations and the mapped structs
+  migrate lock-status    tell whether an instance currently holds the migration lock
+
+Run "oxygen <command> -h" for the flags of a command.
+
+Global flags:
+`
+
+// Run parses the global flags and runs the command given in args, serve if there is none.
+func Run(args []string) error {
+	var cmdArgs setting.CommandLineArgs
+	fs := flag.NewFlagSet("oxygen", flag.ContinueOnError)
+	fs.StringVar(&cmdArgs.Config, "config", "", "path to config file")
+	fs.StringVar(&cmdArgs.HomePath, "homepath", "", "path to oxygen install/home path, defaults to working directory")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usageText)
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := setting.NewCfg()
+	if err := cfg.Load(cmdArgs); err != nil {
+		return err
+	}
+
+	command, rest := "serve", fs.Args()
+	if len(rest) > 0 {
+		command, rest = rest[0], rest[1:]
+	}
+
+	switch command {
+	case "serve":
+		return runServe(cfg, rest)
+	case "migrate":
+		return runMigrate(cfg, rest)
+	default:
+		fmt.Fprintf(fs.Output(), "unknown command %q\n\n", command)
+		fs.Usage()
+		return ErrUsage
+	}
+}
+
+// parseFlags parses args, errors other than flag.ErrHelp are reported as ErrUsage.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return ErrUsage
+	}
+	return err
+}
+
+// migrationFlags are the migration locking flags, their defaults come from the [database] section.
+type migrationFlags struct {
+	lock        bool
+	lockTimeout int
+}
+
+func addMigrationFlags(fs *flag.FlagSet, cfg *setting.Cfg) *migrationFlags {
+	sec := cfg.Raw.Section("database")
+	f := &migrationFlags{}
+	fs.BoolVar(&f.lock, "lock", sec.Key("migration_locking").MustBool(true), "lock the database while migrating, so that only one instance runs the migrations")
+	fs.IntVar(&f.lockTimeout, "lock-timeout", sec.Key("locking_attempt_timeout_sec").MustInt(), "seconds to wait for the migration lock")
+	return f
+}
+
+// apply writes the flags to the configuration, it has to be called before the store is provided.
+func (f *migrationFlags) apply(cfg *setting.Cfg) {
+	cfg.Raw.Section("database").Key("locking_attempt_timeout_sec").SetValue(strconv.Itoa(f.lockTimeout))
+}
+
+// mappedStructs are the xorm mapped structs migrate diff checks the migrations against.
+var mappedStructs = []interface{}{
+	&user.User{},
+	&sqlstore.OutboxEvent{},
+	&login.UserSession{},
+}
+
+func provideStore(cfg *setting.Cfg, eventBus bus.Bus) (*sqlstore.SQLStore, error) {
+	store, err := sqlstore.ProvideService(cfg, &migrations.OxygenMigrations{}, eventBus, false)
+	if err != nil {
+		return nil, err
+	}
+	store.RegisterMigrations(migrations.ProvideLoginMigrations())
+	return store, nil
+}
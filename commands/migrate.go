@@ -0,0 +1,222 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Suj8K/oxygen-go/bus"
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+	"github.com/Suj8K/oxygen-go/setting"
+)
+
+const migrateUsageText = `Usage: oxygen migrate <up|status|plan|verify|diff|lock-status> [flags]
+`
+
+func runMigrate(cfg *setting.Cfg, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsageText)
+		return ErrUsage
+	}
+
+	switch args[0] {
+	case "up":
+		return runMigrateUp(cfg, args[1:])
+	case "status":
+		return runMigrateStatus(cfg, args[1:])
+	case "plan":
+		return runMigratePlan(cfg, args[1:])
+	case "verify":
+		return runMigrateVerify(cfg, args[1:])
+	case "diff":
+		return runMigrateDiff(cfg, args[1:])
+	case "lock-status":
+		return runMigrateLockStatus(cfg, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q\n\n%s", args[0], migrateUsageText)
+		return ErrUsage
+	}
+}
+
+// runMigrateUp runs the pending migrations, skip_migrations is ignored so that a deploy job can run
+// the migrations on behalf of instances configured to skip them.
+func runMigrateUp(cfg *setting.Cfg, args []string) error {
+	fs := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+	migrationFlags := addMigrationFlags(fs, cfg)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	migrationFlags.apply(cfg)
+
+	store, err := provideStore(cfg, bus.ProvideBus())
+	if err != nil {
+		return err
+	}
+	if err := store.RunMigrations(migrationFlags.lock); err != nil {
+		return err
+	}
+
+	log.Println("migrations are up to date")
+	return nil
+}
+
+func runMigrateStatus(cfg *setting.Cfg, args []string) error {
+	fs := flag.NewFlagSet("migrate status", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format, text or json")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	store, err := provideStore(cfg, bus.ProvideBus())
+	if err != nil {
+		return err
+	}
+	statuses, err := store.MigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		return writeJSON(statuses)
+	}
+	return migrator.WriteStatusText(os.Stdout, statuses)
+}
+
+func runMigratePlan(cfg *setting.Cfg, args []string) error {
+	fs := flag.NewFlagSet("migrate plan", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format, text or json")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	store, err := provideStore(cfg, bus.ProvideBus())
+	if err != nil {
+		return err
+	}
+	plan, err := store.PlanMigrations()
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		return plan.WriteJSON(os.Stdout)
+	}
+	return plan.WriteText(os.Stdout)
+}
+
+// runMigrateVerify reports the applied migrations that differ from the code, it fails when there is drift.
+func runMigrateVerify(cfg *setting.Cfg, args []string) error {
+	fs := flag.NewFlagSet("migrate verify", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format, text or json")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	store, err := provideStore(cfg, bus.ProvideBus())
+	if err != nil {
+		return err
+	}
+	report, err := store.VerifyMigrations()
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		err = writeJSON(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+	if report.HasDrift() {
+		return migrator.ErrMigrationDrift
+	}
+	return nil
+}
+
+// runMigrateDiff compares the live schema with the migrations and the mapped structs. With -generate it
+// prints the migrations declaring what the structs expect instead of the differences.
+func runMigrateDiff(cfg *setting.Cfg, args []string) error {
+	fs := flag.NewFlagSet("migrate diff", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format, text or json")
+	generate := fs.Bool("generate", false, "print the migrations missing for the mapped structs")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	store, err := provideStore(cfg, bus.ProvideBus())
+	if err != nil {
+		return err
+	}
+	diff, err := store.DiffSchema(mappedStructs...)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *generate:
+		return diff.WriteMigrations(os.Stdout)
+	case *format == "json":
+		return writeJSON(diff)
+	default:
+		return diff.WriteText(os.Stdout)
+	}
+}
+
+func runMigrateLockStatus(cfg *setting.Cfg, args []string) error {
+	fs := flag.NewFlagSet("migrate lock-status", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format, text or json")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+
+	store, err := provideStore(cfg, bus.ProvideBus())
+	if err != nil {
+		return err
+	}
+	locked, err := store.IsMigrationLocked()
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		return writeJSON(map[string]bool{"locked": locked})
+	}
+	if locked {
+		fmt.Println("migration lock is held by another instance")
+	} else {
+		fmt.Println("migration lock is free")
+	}
+	return nil
+}
+
+func validateFormat(format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q, expected text or json", format)
+	}
+	return nil
+}
+
+func writeJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
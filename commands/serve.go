@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/Suj8K/oxygen-go/api"
+	"github.com/Suj8K/oxygen-go/bus"
+	"github.com/Suj8K/oxygen-go/services/outbox"
+	"github.com/Suj8K/oxygen-go/setting"
+)
+
+// runServe runs the migrations, unless skip_migrations is set, and starts the HTTP server.
+func runServe(cfg *setting.Cfg, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	migrationFlags := addMigrationFlags(fs, cfg)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	migrationFlags.apply(cfg)
+
+	// Init event bus, services subscribe to the events published after commit here
+	eventBus := bus.ProvideBus()
+
+	// Init DB service
+	dbService, err := provideStore(cfg, eventBus)
+	if err != nil {
+		return err
+	}
+
+	// Perform Migrations
+	if err := dbService.Migrate(migrationFlags.lock); err != nil {
+		return err
+	}
+
+	// Relay the events of the outbox to the webhook
+	if cfg.EventOutboxEnabled {
+		relay, err := outbox.ProvideRelay(cfg, dbService)
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := relay.Run(context.Background()); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+
+	// Run Http server
+	apiServer, err := api.NewAPIServer(cfg, dbService)
+	if err != nil {
+		return err
+	}
+	apiServer.Run()
+	return nil
+}
@@ -1,27 +1,21 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"github.com/Suj8K/oxygen-go/api"
-	"github.com/Suj8K/oxygen-go/services/sqlstore"
-	"github.com/Suj8K/oxygen-go/services/sqlstore/migrations"
+	"github.com/Suj8K/oxygen-go/commands"
+	"os"
 )
 
 func main() {
-
-	// Init DB service
-	dbService, dbError := sqlstore.ProvideService(&migrations.OxygenMigrations{}, false)
-	if dbError != nil {
-		fmt.Println(dbError)
-	}
-
-	// Perform Migrations
-	err := dbService.Migrate(false)
-	if err != nil {
+	err := commands.Run(os.Args[1:])
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, commands.ErrUsage):
+		os.Exit(2)
+	default:
 		fmt.Println(err)
+		os.Exit(1)
 	}
-
-	// Run Http server
-	apiServer := api.NewAPIServer(":9096", dbService)
-	apiServer.Run()
 }
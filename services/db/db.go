@@ -9,13 +9,13 @@ import (
 )
 
 type DB interface {
-	//WithTransactionalDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error
+	WithTransactionalDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error
 	WithDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error
 	WithNewDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error
 	GetDialect() migrator.Dialect
 	//GetDBType() core.DbType
 	GetSqlxSession() *session.SessionDB
-	//InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 	Quote(value string) string
 	// RecursiveQueriesAreSupported runs a dummy recursive query and it returns true
 	// if the query runs successfully or false if it fails with mysqlerr.ER_PARSE_ERROR error or any other error
@@ -0,0 +1,129 @@
+package impl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Suj8K/oxygen-go/services/db"
+	"github.com/Suj8K/oxygen-go/services/login"
+	"github.com/Suj8K/oxygen-go/services/user"
+	"github.com/Suj8K/oxygen-go/setting"
+	"github.com/Suj8K/oxygen-go/util"
+)
+
+// tokenBytes is the number of random bytes of a session token
+const tokenBytes = 32
+
+type Service struct {
+	db          db.DB
+	store       store
+	users       user.Service
+	maxLifetime time.Duration
+}
+
+func ProvideService(
+	db db.DB,
+	users user.Service,
+	cfg *setting.Cfg,
+) (login.Service, error) {
+	store := ProvideStore(db)
+	s := &Service{
+		db:          db,
+		store:       &store,
+		users:       users,
+		maxLifetime: cfg.LoginMaxLifetime,
+	}
+
+	return s, nil
+}
+
+// Login verifies the password before telling that the user is disabled, only a client knowing the
+// password learns it. The last seen time of the user is updated with the creation of the session.
+func (s *Service) Login(ctx context.Context, cmd *login.LoginCommand) (*login.Session, error) {
+	usr, err := s.users.GetByLogin(ctx, &user.GetUserByLoginQuery{LoginOrEmail: cmd.User})
+	if errors.Is(err, user.ErrUserNotFound) {
+		// an unknown login takes as long as a wrong password
+		_, _ = util.EncodePassword(cmd.Password, "")
+		return nil, login.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	valid, err := usr.ValidatePassword(cmd.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, login.ErrInvalidCredentials
+	}
+	if usr.IsDisabled {
+		return nil, login.ErrUserDisabled
+	}
+
+	token, err := util.RandomHex(tokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &login.UserSession{
+		UserID:    usr.ID,
+		TokenHash: hashToken(token),
+		Created:   now,
+		ExpiresAt: now.Add(s.maxLifetime),
+	}
+
+	err = s.db.InTransaction(ctx, func(ctx context.Context) error {
+		if err := s.users.UpdateLastSeenAt(ctx, &user.UpdateUserLastSeenAtCommand{UserID: usr.ID}); err != nil {
+			return err
+		}
+		return s.store.InsertSession(ctx, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+	usr.LastSeenAt = now
+
+	return &login.Session{
+		ID:        session.ID,
+		Token:     token,
+		UserID:    usr.ID,
+		ExpiresAt: session.ExpiresAt,
+		User:      usr,
+	}, nil
+}
+
+// GetSession returns the session of a token with its user, the sessions of a disabled or deleted user are rejected.
+func (s *Service) GetSession(ctx context.Context, token string) (*login.Session, error) {
+	session, err := s.store.GetSessionByTokenHash(ctx, hashToken(token), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	usr, err := s.users.GetByID(ctx, &user.GetUserByIDQuery{ID: session.UserID})
+	if errors.Is(err, user.ErrUserNotFound) {
+		return nil, login.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usr.IsDisabled {
+		return nil, login.ErrUserDisabled
+	}
+
+	return &login.Session{
+		ID:        session.ID,
+		UserID:    session.UserID,
+		ExpiresAt: session.ExpiresAt,
+		User:      usr,
+	}, nil
+}
+
+// hashToken returns the hash a session token is stored as, a leaked table does not leak the tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
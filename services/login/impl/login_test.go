@@ -0,0 +1,92 @@
+package impl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Suj8K/oxygen-go/services/login"
+	"github.com/Suj8K/oxygen-go/services/sqlstore"
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrations"
+	"github.com/Suj8K/oxygen-go/services/user"
+	userimpl "github.com/Suj8K/oxygen-go/services/user/impl"
+	"github.com/Suj8K/oxygen-go/setting"
+)
+
+func TestLogin(t *testing.T) {
+	ss := sqlstore.InitTestDB(t, &migrations.OxygenMigrations{}, migrations.ProvideLoginMigrations())
+	users, err := userimpl.ProvideService(ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := setting.NewCfg()
+	cfg.LoginMaxLifetime = time.Hour
+	s, err := ProvideService(ss, users, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	usr, err := users.Create(ctx, &user.CreateUserCommand{Login: "bob", Email: "bob@x", Password: "pw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.Create(ctx, &user.CreateUserCommand{Login: "eve", Email: "eve@x", Password: "pw", IsDisabled: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	failures := []struct {
+		cmd  login.LoginCommand
+		want error
+	}{
+		{cmd: login.LoginCommand{User: "ghost", Password: "pw"}, want: login.ErrInvalidCredentials},
+		{cmd: login.LoginCommand{User: "bob", Password: "wrong"}, want: login.ErrInvalidCredentials},
+		{cmd: login.LoginCommand{User: "eve", Password: "pw"}, want: login.ErrUserDisabled},
+	}
+	for _, tt := range failures {
+		if _, err := s.Login(ctx, &tt.cmd); !errors.Is(err, tt.want) {
+			t.Errorf("the login of %s with %q returned %v, expected %v", tt.cmd.User, tt.cmd.Password, err, tt.want)
+		}
+	}
+
+	before := time.Now().Add(-time.Second)
+	session, err := s.Login(ctx, &login.LoginCommand{User: "bob@x", Password: "pw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := make([]*login.UserSession, 0)
+	if err := ss.GetEngine().Find(&rows); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(session.Token))
+	if len(rows) != 1 || rows[0].UserID != usr.ID || rows[0].TokenHash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("the login stored the sessions %+v, expected one with the sha256 of the token", rows)
+	}
+
+	stored, err := users.GetByID(ctx, &user.GetUserByIDQuery{ID: usr.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.LastSeenAt.Before(before) {
+		t.Errorf("the login did not update last_seen_at, it is %s", stored.LastSeenAt)
+	}
+
+	got, err := s.GetSession(ctx, session.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != session.ID || got.User.ID != usr.ID {
+		t.Errorf("GetSession returned %+v, expected the session of the login", got)
+	}
+
+	if _, err := ss.GetEngine().ID(session.ID).Cols("expires_at").Update(&login.UserSession{ExpiresAt: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetSession(ctx, session.Token); !errors.Is(err, login.ErrSessionNotFound) {
+		t.Errorf("GetSession of an expired session returned %v, expected ErrSessionNotFound", err)
+	}
+}
@@ -0,0 +1,48 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/Suj8K/oxygen-go/services/db"
+	"github.com/Suj8K/oxygen-go/services/login"
+)
+
+type store interface {
+	InsertSession(context.Context, *login.UserSession) error
+	GetSessionByTokenHash(ctx context.Context, tokenHash string, now time.Time) (*login.UserSession, error)
+}
+
+type sqlStore struct {
+	db db.DB
+}
+
+func ProvideStore(db db.DB) sqlStore {
+	return sqlStore{db: db}
+}
+
+func (ss *sqlStore) InsertSession(ctx context.Context, session *login.UserSession) error {
+	return ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(session)
+		return err
+	})
+}
+
+// GetSessionByTokenHash returns the session of a token hash, unless it expired before now.
+func (ss *sqlStore) GetSessionByTokenHash(ctx context.Context, tokenHash string, now time.Time) (*login.UserSession, error) {
+	var session login.UserSession
+	err := ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("token_hash = ? AND expires_at > ?", tokenHash, now).Get(&session)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return login.ErrSessionNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
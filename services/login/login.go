@@ -0,0 +1,12 @@
+package login
+
+import (
+	"context"
+)
+
+type Service interface {
+	// Login authenticates a user by login or email and password, and creates a session for it.
+	Login(context.Context, *LoginCommand) (*Session, error)
+	// GetSession returns the unexpired session of a token.
+	GetSession(ctx context.Context, token string) (*Session, error)
+}
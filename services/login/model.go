@@ -0,0 +1,60 @@
+package login
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Suj8K/oxygen-go/services/user"
+)
+
+// Typed errors
+var (
+	// ErrInvalidCredentials is returned for an unknown login as well as for a wrong password,
+	// so that a client cannot tell which logins exist.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUserDisabled       = errors.New("user is disabled")
+	ErrSessionNotFound    = errors.New("session not found or expired")
+)
+
+type LoginCommand struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+// UserSession is a session as stored in the user_session table, only the hash of its token is stored.
+type UserSession struct {
+	ID        int64     `xorm:"pk autoincr 'id'"`
+	UserID    int64     `xorm:"user_id"`
+	TokenHash string    `xorm:"token_hash"`
+	Created   time.Time `xorm:"created"`
+	ExpiresAt time.Time `xorm:"expires_at"`
+}
+
+func (UserSession) TableName() string {
+	return "user_session"
+}
+
+// Session is a session of a user. Token is only set by Login, it cannot be recovered afterwards.
+type Session struct {
+	ID        int64
+	Token     string
+	UserID    int64
+	ExpiresAt time.Time
+	User      *user.User
+}
+
+// SessionDTO is the public representation of a session created by Login.
+type SessionDTO struct {
+	Token     string        `json:"token"`
+	ExpiresAt time.Time     `json:"expiresAt"`
+	User      *user.UserDTO `json:"user"`
+}
+
+// NewSessionDTO maps a session created by Login to its public representation.
+func NewSessionDTO(s *Session) *SessionDTO {
+	return &SessionDTO{
+		Token:     s.Token,
+		ExpiresAt: s.ExpiresAt,
+		User:      user.NewUserDTO(s.User),
+	}
+}
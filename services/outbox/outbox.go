@@ -0,0 +1,245 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/database"
+
+	"github.com/Suj8K/oxygen-go/events"
+	"github.com/Suj8K/oxygen-go/services/db"
+	"github.com/Suj8K/oxygen-go/services/sqlstore"
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+	"github.com/Suj8K/oxygen-go/setting"
+)
+
+// minBackoff is the delay before the first retry of an event that could not be delivered.
+const minBackoff = time.Second
+
+// Sink receives the events of the outbox. Send is called at least once per event, so it should be
+// idempotent on Message.ID. The events are sent in the order of their ids: the events of a
+// transaction keep the order they were published in, but the ids are taken before the commit,
+// so two concurrent transactions may be sent in the reverse order of their commits.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Message is an outbox row handed to the sinks.
+type Message struct {
+	ID      int64
+	Type    string
+	Payload []byte
+	Created time.Time
+}
+
+// Decode returns the typed event stored in the message, e.g. *events.UserCreated.
+func (m *Message) Decode() (interface{}, error) {
+	var evt interface{}
+	switch m.Type {
+	case sqlstore.OutboxEventType(&events.UserCreated{}):
+		evt = &events.UserCreated{}
+	case sqlstore.OutboxEventType(&events.UserUpdated{}):
+		evt = &events.UserUpdated{}
+	default:
+		return nil, fmt.Errorf("unknown outbox event type %q", m.Type)
+	}
+
+	if err := json.Unmarshal(m.Payload, evt); err != nil {
+		return nil, fmt.Errorf("failed to decode outbox event %d: %w", m.ID, err)
+	}
+	return evt, nil
+}
+
+// Relay reads the undelivered events of the outbox in order and hands them to the sinks.
+// Every replica may run a relay: a batch is relayed while holding an advisory lock of the database,
+// so a single relay delivers at a time and the others skip the batch. SQLite has no such lock,
+// a single process is expected to use it.
+type Relay struct {
+	db           db.DB
+	sinks        []Sink
+	lockKey      string
+	pollInterval time.Duration
+	batchSize    int
+	maxBackoff   time.Duration
+	retention    time.Duration
+}
+
+// ProvideRelay returns a relay delivering to the webhook of the configuration, if there is one.
+func ProvideRelay(cfg *setting.Cfg, db db.DB) (*Relay, error) {
+	dbName := cfg.Raw.Section("database").Key("name").String()
+	lockKey, err := database.GenerateAdvisoryLockId(dbName, "event_outbox")
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the lock key of the outbox: %w", err)
+	}
+
+	r := &Relay{
+		db:           db,
+		lockKey:      lockKey,
+		pollInterval: cfg.EventOutboxPollInterval,
+		batchSize:    cfg.EventOutboxBatchSize,
+		maxBackoff:   cfg.EventOutboxMaxBackoff,
+		retention:    cfg.EventOutboxRetention,
+	}
+	if cfg.EventOutboxWebhookURL != "" {
+		r.AddSink(NewWebhookSink(cfg.EventOutboxWebhookURL, cfg.EventOutboxWebhookTimeout))
+	}
+	return r, nil
+}
+
+// AddSink registers a sink, it has to be called before Run.
+func (r *Relay) AddSink(sink Sink) {
+	r.sinks = append(r.sinks, sink)
+}
+
+// Run relays the outbox and deletes the expired delivered events every poll interval
+// until the context is cancelled. It fails without sinks, the events would never leave the outbox.
+func (r *Relay) Run(ctx context.Context) error {
+	if r.pollInterval <= 0 {
+		return fmt.Errorf("outbox: invalid poll interval %s", r.pollInterval)
+	}
+	if len(r.sinks) == 0 {
+		return errors.New("outbox: no sink registered")
+	}
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayBatch(ctx); err != nil {
+			log.Println("outbox: failed to relay events:", err)
+		}
+		if _, err := r.Cleanup(ctx); err != nil {
+			log.Println("outbox: failed to delete delivered events:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayBatch delivers the next batch of undelivered events and returns how many were delivered.
+// It stops at the first event that fails, so that the events are never delivered out of order.
+// Nothing is delivered while another relay holds the lock of the outbox.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	delivered := 0
+	// the transaction only pins the connection holding the lock, the events are read and
+	// updated through their own sessions so that SQLite does not keep the database locked
+	err := r.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		lockCfg := migrator.LockCfg{Session: sess.Session, Key: r.lockKey}
+		if err := r.db.GetDialect().Lock(lockCfg); err != nil {
+			if errors.Is(err, migrator.ErrLockDB) {
+				// another relay is delivering the outbox
+				return nil
+			}
+			return err
+		}
+		defer func() {
+			if err := r.db.GetDialect().Unlock(lockCfg); err != nil {
+				log.Println("outbox: failed to release the lock:", err)
+			}
+		}()
+
+		var err error
+		delivered, err = r.relayPending(ctx)
+		return err
+	})
+	return delivered, err
+}
+
+func (r *Relay) relayPending(ctx context.Context) (int, error) {
+	pending := make([]*sqlstore.OutboxEvent, 0)
+	err := r.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("delivered = ?", false).Asc("id").Limit(r.batchSize).Find(&pending)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, evt := range pending {
+		if time.Now().Before(evt.NextAttemptAt) {
+			// backing off, the following events have to wait for this one
+			return delivered, nil
+		}
+
+		msg := &Message{ID: evt.ID, Type: evt.EventType, Payload: []byte(evt.Payload), Created: evt.Created}
+		if sendErr := r.send(ctx, msg); sendErr != nil {
+			if err := r.markFailed(ctx, evt, sendErr); err != nil {
+				return delivered, err
+			}
+			return delivered, sendErr
+		}
+
+		if err := r.markDelivered(ctx, evt); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+func (r *Relay) send(ctx context.Context, msg *Message) error {
+	for _, sink := range r.sinks {
+		if err := sink.Send(ctx, msg); err != nil {
+			return fmt.Errorf("sink %s failed to receive event %d: %w", sink.Name(), msg.ID, err)
+		}
+	}
+	return nil
+}
+
+func (r *Relay) markDelivered(ctx context.Context, evt *sqlstore.OutboxEvent) error {
+	return r.db.WithDbSession(ctx, func(sess *db.Session) error {
+		evt.Delivered = true
+		evt.DeliveredAt = time.Now()
+		evt.Attempts++
+		_, err := sess.ID(evt.ID).Cols("delivered", "delivered_at", "attempts").Update(evt)
+		return err
+	})
+}
+
+func (r *Relay) markFailed(ctx context.Context, evt *sqlstore.OutboxEvent, sendErr error) error {
+	return r.db.WithDbSession(ctx, func(sess *db.Session) error {
+		evt.Attempts++
+		evt.LastError = sendErr.Error()
+		evt.NextAttemptAt = time.Now().Add(r.backoff(evt.Attempts))
+		_, err := sess.ID(evt.ID).Cols("attempts", "last_error", "next_attempt_at").Update(evt)
+		return err
+	})
+}
+
+// Cleanup deletes the events delivered longer than the retention ago and returns how many were deleted.
+// A retention of zero keeps the delivered events.
+func (r *Relay) Cleanup(ctx context.Context) (int64, error) {
+	if r.retention <= 0 {
+		return 0, nil
+	}
+
+	var deleted int64
+	err := r.db.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		deleted, err = sess.Where("delivered = ? AND delivered_at < ?", true, time.Now().Add(-r.retention)).Delete(&sqlstore.OutboxEvent{})
+		return err
+	})
+	return deleted, err
+}
+
+// backoff doubles the delay with every failed attempt, up to the configured maximum.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := minBackoff
+	for i := 1; i < attempts && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.maxBackoff {
+		return r.maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,158 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Suj8K/oxygen-go/services/db"
+	"github.com/Suj8K/oxygen-go/services/sqlstore"
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrations"
+)
+
+// recordingSink records the ids it receives and fails while err is set.
+type recordingSink struct {
+	ids []int64
+	err error
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Send(_ context.Context, msg *Message) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.ids = append(s.ids, msg.ID)
+	return nil
+}
+
+func newTestRelay(t *testing.T, sink Sink) (*Relay, *sqlstore.SQLStore) {
+	t.Helper()
+	ss := sqlstore.InitTestDB(t, &migrations.OxygenMigrations{})
+	r := &Relay{
+		db:           ss,
+		lockKey:      "1",
+		pollInterval: time.Second,
+		batchSize:    10,
+		maxBackoff:   time.Minute,
+		retention:    time.Hour,
+	}
+	r.AddSink(sink)
+	return r, ss
+}
+
+func insertEvents(t *testing.T, ss *sqlstore.SQLStore, rows ...*sqlstore.OutboxEvent) {
+	t.Helper()
+	err := ss.WithDbSession(context.Background(), func(sess *db.Session) error {
+		for _, row := range rows {
+			if row.EventType == "" {
+				row.EventType, row.Payload = "testEvent", "{}"
+			}
+			if _, err := sess.UseBool("delivered").Insert(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getEvent(t *testing.T, ss *sqlstore.SQLStore, id int64) *sqlstore.OutboxEvent {
+	t.Helper()
+	evt := &sqlstore.OutboxEvent{}
+	err := ss.WithDbSession(context.Background(), func(sess *db.Session) error {
+		_, err := sess.ID(id).Get(evt)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return evt
+}
+
+func TestRelayBatch(t *testing.T) {
+	sink := &recordingSink{err: errors.New("unavailable")}
+	r, ss := newTestRelay(t, sink)
+	ctx := context.Background()
+	now := time.Now()
+	insertEvents(t, ss,
+		&sqlstore.OutboxEvent{Created: now, NextAttemptAt: now},
+		&sqlstore.OutboxEvent{Created: now, NextAttemptAt: now},
+		&sqlstore.OutboxEvent{Created: now, NextAttemptAt: now},
+	)
+
+	delivered, err := r.RelayBatch(ctx)
+	if err == nil || delivered != 0 {
+		t.Fatalf("RelayBatch delivered %d events with error %v, expected the error of the sink", delivered, err)
+	}
+	failed := getEvent(t, ss, 1)
+	if failed.Delivered || failed.Attempts != 1 || failed.LastError == "" || !failed.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("the failed event is %+v, expected an attempt with the error and a retry later", failed)
+	}
+
+	// the failed event backs off and the later ones wait for it, even though the sink recovered
+	sink.err = nil
+	delivered, err = r.RelayBatch(ctx)
+	if err != nil || delivered != 0 || len(sink.ids) != 0 {
+		t.Fatalf("RelayBatch sent %v while backing off, error %v", sink.ids, err)
+	}
+
+	err = ss.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.ID(1).Cols("next_attempt_at").Update(&sqlstore.OutboxEvent{NextAttemptAt: now})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivered, err = r.RelayBatch(ctx)
+	if err != nil || delivered != 3 {
+		t.Fatalf("RelayBatch delivered %d events with error %v, expected 3", delivered, err)
+	}
+	if len(sink.ids) != 3 || sink.ids[0] != 1 || sink.ids[1] != 2 || sink.ids[2] != 3 {
+		t.Errorf("the sink received %v, expected [1 2 3]", sink.ids)
+	}
+	for id, attempts := range map[int64]int{1: 2, 2: 1, 3: 1} {
+		evt := getEvent(t, ss, id)
+		if !evt.Delivered || evt.DeliveredAt.IsZero() || evt.Attempts != attempts {
+			t.Errorf("event %d is %+v, expected delivered after %d attempts", id, evt, attempts)
+		}
+	}
+
+	if delivered, err := r.RelayBatch(ctx); err != nil || delivered != 0 {
+		t.Errorf("RelayBatch delivered %d events with error %v once the outbox is empty", delivered, err)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	r, ss := newTestRelay(t, &recordingSink{})
+	now := time.Now()
+	old := now.Add(-2 * time.Hour)
+	insertEvents(t, ss,
+		&sqlstore.OutboxEvent{Created: old, NextAttemptAt: old, Delivered: true, DeliveredAt: old},
+		&sqlstore.OutboxEvent{Created: old, NextAttemptAt: old, Delivered: true, DeliveredAt: now},
+		&sqlstore.OutboxEvent{Created: old, NextAttemptAt: old},
+	)
+
+	deleted, err := r.Cleanup(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Fatalf("Cleanup deleted %d events, expected the one delivered before the retention", deleted)
+	}
+	if evt := getEvent(t, ss, 1); evt.ID != 0 {
+		t.Errorf("the expired event %+v is kept", evt)
+	}
+}
+
+func TestRunWithoutSink(t *testing.T) {
+	r := &Relay{pollInterval: time.Second}
+	if err := r.Run(context.Background()); err == nil {
+		t.Error("Run without sink succeeded, expected an error")
+	}
+}
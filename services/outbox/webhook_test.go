@@ -0,0 +1,38 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookSink(t *testing.T) {
+	status := http.StatusNoContent
+	var received webhookBody
+	var eventID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		eventID = r.Header.Get("X-Oxygen-Event-Id")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode the webhook body: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL, time.Second)
+	msg := &Message{ID: 7, Type: "userCreated", Payload: []byte(`{"ID":3}`), Created: time.Now()}
+	if err := sink.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if eventID != "7" || received.ID != 7 || received.Type != "userCreated" || string(received.Payload) != `{"ID":3}` {
+		t.Errorf("the webhook received %+v with event id %q", received, eventID)
+	}
+
+	status = http.StatusInternalServerError
+	if err := sink.Send(context.Background(), msg); err == nil {
+		t.Error("Send succeeded on a 500 response, expected an error")
+	}
+}
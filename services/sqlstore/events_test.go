@@ -0,0 +1,64 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Suj8K/oxygen-go/bus"
+)
+
+type testItemCreated struct {
+	Name string
+}
+
+func TestPublishAfterCommit(t *testing.T) {
+	ss := InitTestDB(t, &testMigrations{})
+	ctx := context.Background()
+
+	var published []string
+	ss.bus.(*bus.InProcBus).Subscribe(func(ctx context.Context, e *testItemCreated) error {
+		published = append(published, e.Name)
+		return nil
+	})
+	create := func(ctx context.Context, name string) error {
+		return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+			if _, err := sess.Exec("INSERT INTO test_item (name, value) VALUES (?, 0)", name); err != nil {
+				return err
+			}
+			sess.PublishAfterCommit(&testItemCreated{Name: name})
+			return nil
+		})
+	}
+
+	err := ss.InTransaction(ctx, func(ctx context.Context) error {
+		if err := create(ctx, "a"); err != nil {
+			return err
+		}
+		if len(published) != 0 {
+			t.Errorf("%v are published before the commit", published)
+		}
+		return create(ctx, "b")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(published); got != 2 || published[0] != "a" || published[1] != "b" {
+		t.Errorf("%v are published after the commit, expected [a b]", published)
+	}
+
+	published = nil
+	failed := errors.New("failed")
+	err = ss.InTransaction(ctx, func(ctx context.Context) error {
+		if err := create(ctx, "c"); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("InTransaction returned %v, expected the error of fn", err)
+	}
+	if len(published) != 0 {
+		t.Errorf("%v are published after a rollback", published)
+	}
+}
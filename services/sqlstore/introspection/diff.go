@@ -0,0 +1,249 @@
+package introspection
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+	"xorm.io/xorm"
+)
+
+// SourceMigrations is the source of the differences between the live schema and the migrations.
+const SourceMigrations = "migrations"
+
+type Kind string
+
+const (
+	MissingTable   Kind = "missing table"
+	MissingColumn  Kind = "missing column"
+	MissingIndex   Kind = "missing index"
+	ColumnMismatch Kind = "column mismatch"
+	IndexMismatch  Kind = "index mismatch"
+	ExtraColumn    Kind = "extra column"
+)
+
+// Difference is a table, column or index expected by Source that is missing or differs.
+type Difference struct {
+	Kind Kind `json:"kind"`
+	// Source is SourceMigrations when the live schema differs from the migrations,
+	// or the name of the struct when a struct maps something no migration declares.
+	Source string           `json:"source"`
+	Table  string           `json:"table"`
+	Column *migrator.Column `json:"column,omitempty"`
+	Index  *migrator.Index  `json:"index,omitempty"`
+	Detail string           `json:"detail,omitempty"`
+	// expected is the table of a missing table
+	expected *migrator.Table
+}
+
+type Diff struct {
+	Differences []*Difference `json:"differences"`
+}
+
+func (d *Diff) Empty() bool {
+	return len(d.Differences) == 0
+}
+
+func (d *Diff) add(diff *Difference) {
+	d.Differences = append(d.Differences, diff)
+}
+
+// DiffSchema compares the live schema with the tables declared by the migrations. Types are compared
+// as rendered by the dialect, lengths are ignored.
+func DiffSchema(dialect migrator.Dialect, live []*migrator.Table, declared []*migrator.Table) *Diff {
+	diff := &Diff{Differences: make([]*Difference, 0)}
+	for _, expected := range declared {
+		actual := findTable(live, expected.Name)
+		if actual == nil {
+			diff.add(&Difference{Kind: MissingTable, Source: SourceMigrations, Table: expected.Name, expected: expected})
+			continue
+		}
+
+		for _, col := range expected.Columns {
+			liveCol := findColumn(actual, col.Name)
+			if liveCol == nil {
+				diff.add(&Difference{Kind: MissingColumn, Source: SourceMigrations, Table: expected.Name, Column: col})
+				continue
+			}
+			if detail := compareColumns(dialect, col, liveCol, true); detail != "" {
+				diff.add(&Difference{Kind: ColumnMismatch, Source: SourceMigrations, Table: expected.Name, Column: col, Detail: detail})
+			}
+		}
+		for _, col := range actual.Columns {
+			if findColumn(expected, col.Name) == nil {
+				diff.add(&Difference{Kind: ExtraColumn, Source: SourceMigrations, Table: expected.Name, Column: col})
+			}
+		}
+
+		for _, idx := range expected.Indices {
+			if detail, found := compareIndex(actual, expected.Name, idx); !found || detail != "" {
+				kind := MissingIndex
+				if found {
+					kind = IndexMismatch
+				}
+				diff.add(&Difference{Kind: kind, Source: SourceMigrations, Table: expected.Name, Index: idx, Detail: detail})
+			}
+		}
+	}
+	return diff
+}
+
+// DiffStructs reports the tables, columns and indices xorm mapped structs expect but no migration declares,
+// and the columns whose type differs. Nullability and defaults are not compared, the struct tags rarely state them.
+func DiffStructs(engine *xorm.Engine, dialect migrator.Dialect, declared []*migrator.Table, beans ...interface{}) (*Diff, error) {
+	diff := &Diff{Differences: make([]*Difference, 0)}
+	for _, bean := range beans {
+		expected, err := StructTable(engine, bean)
+		if err != nil {
+			return nil, err
+		}
+		source := structName(bean)
+
+		table := findTable(declared, expected.Name)
+		if table == nil {
+			diff.add(&Difference{Kind: MissingTable, Source: source, Table: expected.Name, expected: expected})
+			continue
+		}
+
+		for _, col := range expected.Columns {
+			declaredCol := findColumn(table, col.Name)
+			if declaredCol == nil {
+				diff.add(&Difference{Kind: MissingColumn, Source: source, Table: expected.Name, Column: col})
+				continue
+			}
+			if detail := compareColumns(dialect, declaredCol, col, false); detail != "" {
+				diff.add(&Difference{Kind: ColumnMismatch, Source: source, Table: expected.Name, Column: col, Detail: detail})
+			}
+		}
+
+		for _, idx := range expected.Indices {
+			if _, found := compareIndex(table, expected.Name, idx); !found {
+				diff.add(&Difference{Kind: MissingIndex, Source: source, Table: expected.Name, Index: idx})
+			}
+		}
+	}
+	return diff, nil
+}
+
+// compareColumns describes how actual differs from expected, it returns an empty string if they match.
+func compareColumns(dialect migrator.Dialect, expected, actual *migrator.Column, strict bool) string {
+	details := make([]string, 0)
+	expectedType, actualType := baseType(dialect.SQLType(expected)), baseType(dialect.SQLType(actual))
+	if expectedType != actualType {
+		details = append(details, fmt.Sprintf("type is %s, expected %s", actualType, expectedType))
+	}
+	if !strict {
+		return strings.Join(details, ", ")
+	}
+
+	if expected.Nullable != actual.Nullable && !expected.IsPrimaryKey {
+		details = append(details, fmt.Sprintf("nullable is %t, expected %t", actual.Nullable, expected.Nullable))
+	}
+	if normalizeDefault(expected.Default) != normalizeDefault(actual.Default) && !expected.IsAutoIncrement {
+		details = append(details, fmt.Sprintf("default is %q, expected %q", actual.Default, expected.Default))
+	}
+	return strings.Join(details, ", ")
+}
+
+// compareIndex looks up idx by name in table and describes how the found index differs.
+func compareIndex(table *migrator.Table, tableName string, idx *migrator.Index) (string, bool) {
+	name := (&migrator.Index{Name: idx.Name, Type: idx.Type, Cols: idx.Cols}).XName(tableName)
+	for _, actual := range table.Indices {
+		if !strings.EqualFold(actual.XName(table.Name), name) {
+			continue
+		}
+		details := make([]string, 0)
+		if (actual.Type == migrator.UniqueIndex) != (idx.Type == migrator.UniqueIndex) {
+			details = append(details, "uniqueness differs")
+		}
+		if strings.Join(actual.Cols, ",") != strings.Join(idx.Cols, ",") {
+			details = append(details, fmt.Sprintf("columns are (%s), expected (%s)", strings.Join(actual.Cols, ", "), strings.Join(idx.Cols, ", ")))
+		}
+		return strings.Join(details, ", "), true
+	}
+	return "", false
+}
+
+// typeAliases maps the type names that differ between what the dialects render and what the databases report.
+var typeAliases = map[string]string{
+	"SERIAL":    "INTEGER",
+	"INT":       "INTEGER",
+	"INT4":      "INTEGER",
+	"BIGSERIAL": "BIGINT",
+	"INT8":      "BIGINT",
+	"BOOLEAN":   "BOOL",
+	"NVARCHAR":  "VARCHAR",
+	"DATETIME":  "TIMESTAMP",
+}
+
+func baseType(sqlType string) string {
+	t := strings.ToUpper(strings.TrimSpace(sqlType))
+	if i := strings.IndexAny(t, "( "); i >= 0 {
+		t = t[:i]
+	}
+	if alias, ok := typeAliases[t]; ok {
+		return alias
+	}
+	return t
+}
+
+func normalizeDefault(value string) string {
+	v := strings.TrimSpace(value)
+	if i := strings.Index(v, "::"); i >= 0 {
+		v = v[:i]
+	}
+	v = strings.ToLower(strings.Trim(v, "'\""))
+	switch v {
+	case "false":
+		return "0"
+	case "true":
+		return "1"
+	case "null":
+		return ""
+	}
+	return v
+}
+
+func findTable(tables []*migrator.Table, name string) *migrator.Table {
+	for _, t := range tables {
+		if strings.EqualFold(t.Name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+func findColumn(table *migrator.Table, name string) *migrator.Column {
+	for _, col := range table.Columns {
+		if strings.EqualFold(col.Name, name) {
+			return col
+		}
+	}
+	return nil
+}
+
+// WriteText writes one line per difference.
+func (d *Diff) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	if d.Empty() {
+		sb.WriteString("no differences\n")
+	}
+	for _, diff := range d.Differences {
+		fmt.Fprintf(&sb, "%s: %s", diff.Kind, diff.Table)
+		switch {
+		case diff.Column != nil:
+			fmt.Fprintf(&sb, ".%s", diff.Column.Name)
+		case diff.Index != nil:
+			fmt.Fprintf(&sb, " index (%s)", strings.Join(diff.Index.Cols, ", "))
+		}
+		fmt.Fprintf(&sb, " [%s]", diff.Source)
+		if diff.Detail != "" {
+			fmt.Fprintf(&sb, ": %s", diff.Detail)
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,114 @@
+package introspection
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+)
+
+// typeConstants maps the type names to the migrator constants the generated code uses.
+// Strings are declared as NVARCHAR in the migrations, xorm maps them to VARCHAR.
+var typeConstants = map[string]string{
+	migrator.DB_Bit:        "DB_Bit",
+	migrator.DB_TinyInt:    "DB_TinyInt",
+	migrator.DB_SmallInt:   "DB_SmallInt",
+	migrator.DB_Int:        "DB_Int",
+	migrator.DB_Integer:    "DB_Integer",
+	migrator.DB_BigInt:     "DB_BigInt",
+	migrator.DB_Char:       "DB_Char",
+	migrator.DB_Varchar:    "DB_NVarchar",
+	migrator.DB_NVarchar:   "DB_NVarchar",
+	migrator.DB_Text:       "DB_Text",
+	migrator.DB_MediumText: "DB_MediumText",
+	migrator.DB_LongText:   "DB_LongText",
+	migrator.DB_Uuid:       "DB_Uuid",
+	migrator.DB_Date:       "DB_Date",
+	migrator.DB_DateTime:   "DB_DateTime",
+	migrator.DB_TimeStamp:  "DB_TimeStamp",
+	migrator.DB_Decimal:    "DB_Decimal",
+	migrator.DB_Float:      "DB_Float",
+	migrator.DB_Double:     "DB_Double",
+	migrator.DB_Blob:       "DB_Blob",
+	migrator.DB_Bool:       "DB_Bool",
+}
+
+// WriteMigrations writes the AddTableMigration, AddColumnMigration and AddIndexMigration calls declaring what
+// the structs expect, in the form of the migrations package which dot imports the migrator.
+// Differences found against the live schema are not generated, they are pending or failed migrations.
+func (d *Diff) WriteMigrations(w io.Writer) error {
+	var sb strings.Builder
+	for _, diff := range d.Differences {
+		if diff.Source == SourceMigrations {
+			continue
+		}
+
+		switch diff.Kind {
+		case MissingTable:
+			fmt.Fprintf(&sb, "mg.AddMigration(%q, NewAddTableMigration(Table{\n", "create "+diff.Table+" table")
+			fmt.Fprintf(&sb, "\tName: %q,\n\tColumns: []*Column{\n", diff.Table)
+			for _, col := range diff.expected.Columns {
+				fmt.Fprintf(&sb, "\t\t%s,\n", columnLiteral(col))
+			}
+			sb.WriteString("\t},\n}))\n")
+			for _, idx := range diff.expected.Indices {
+				writeAddIndex(&sb, diff.Table, idx)
+			}
+		case MissingColumn:
+			fmt.Fprintf(&sb, "mg.AddMigration(%q, NewAddColumnMigration(Table{Name: %q}, %s))\n",
+				"add column "+diff.Column.Name+" to "+diff.Table, diff.Table, columnLiteral(diff.Column))
+		case MissingIndex:
+			writeAddIndex(&sb, diff.Table, diff.Index)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeAddIndex(sb *strings.Builder, table string, idx *migrator.Index) {
+	kind := "index"
+	if idx.Type == migrator.UniqueIndex {
+		kind = "unique index"
+	}
+	fmt.Fprintf(sb, "mg.AddMigration(%q, NewAddIndexMigration(Table{Name: %q}, %s))\n",
+		fmt.Sprintf("add %s %s.%s", kind, table, strings.Join(idx.Cols, "_")), table, indexLiteral(table, idx))
+}
+
+func columnLiteral(col *migrator.Column) string {
+	typ, ok := typeConstants[strings.ToUpper(col.Type)]
+	if !ok {
+		typ = fmt.Sprintf("%q", col.Type)
+	}
+
+	fields := []string{fmt.Sprintf("Name: %q", col.Name), "Type: " + typ}
+	if col.IsPrimaryKey {
+		fields = append(fields, "IsPrimaryKey: true")
+	}
+	if col.IsAutoIncrement {
+		fields = append(fields, "IsAutoIncrement: true")
+	}
+	if col.Length > 0 {
+		fields = append(fields, fmt.Sprintf("Length: %d", col.Length))
+	}
+	fields = append(fields, fmt.Sprintf("Nullable: %t", col.Nullable))
+	if col.Default != "" {
+		fields = append(fields, fmt.Sprintf("Default: %q", col.Default))
+	}
+	return "&Column{" + strings.Join(fields, ", ") + "}"
+}
+
+// indexLiteral leaves out the name when it is the default one, the columns joined by an underscore.
+func indexLiteral(table string, idx *migrator.Index) string {
+	fields := make([]string, 0, 3)
+	name := strings.TrimPrefix(strings.TrimPrefix(idx.Name, "IDX_"+table+"_"), "UQE_"+table+"_")
+	if name != "" && name != strings.Join(idx.Cols, "_") {
+		fields = append(fields, fmt.Sprintf("Name: %q", name))
+	}
+	fields = append(fields, fmt.Sprintf("Cols: %#v", idx.Cols))
+	if idx.Type == migrator.UniqueIndex {
+		fields = append(fields, "Type: UniqueIndex")
+	}
+	return "&Index{" + strings.Join(fields, ", ") + "}"
+}
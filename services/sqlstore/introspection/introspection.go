@@ -0,0 +1,152 @@
+// Package introspection reads the schema of the live database and compares it with the tables
+// declared by the migrations and with the xorm mapped structs.
+package introspection
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+// Inspect reads the tables, columns and indices of the database the engine is connected to.
+// Index names are the full names used in the database, e.g. UQE_user_login.
+func Inspect(engine *xorm.Engine) ([]*migrator.Table, error) {
+	metas, err := engine.DBMetas()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "failed to read database schema", err)
+	}
+
+	tables := make([]*migrator.Table, 0, len(metas))
+	for _, meta := range metas {
+		table := fromSchema(meta)
+		if engine.Dialect().URI().DBType == schemas.SQLITE {
+			if table.Columns, table.PrimaryKeys, err = sqliteColumns(engine, meta.Name); err != nil {
+				return nil, err
+			}
+		}
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables, nil
+}
+
+// StructTable returns the table an xorm mapped struct expects, e.g. &user.User{}.
+func StructTable(engine *xorm.Engine, bean interface{}) (*migrator.Table, error) {
+	meta, err := engine.TableInfo(bean)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map %T: %w", bean, err)
+	}
+	return fromSchema(meta), nil
+}
+
+func fromSchema(meta *schemas.Table) *migrator.Table {
+	table := &migrator.Table{Name: meta.Name, PrimaryKeys: meta.PrimaryKeys}
+	for _, col := range meta.Columns() {
+		table.Columns = append(table.Columns, &migrator.Column{
+			Name:            col.Name,
+			Type:            col.SQLType.Name,
+			Length:          int(col.Length),
+			Length2:         int(col.Length2),
+			Nullable:        col.Nullable,
+			IsPrimaryKey:    col.IsPrimaryKey,
+			IsAutoIncrement: col.IsAutoIncrement,
+			Default:         col.Default,
+		})
+	}
+
+	for _, idx := range meta.Indexes {
+		table.Indices = append(table.Indices, &migrator.Index{
+			Name: idx.XName(meta.Name),
+			Type: idx.Type,
+			Cols: idx.Cols,
+		})
+	}
+	sort.Slice(table.Indices, func(i, j int) bool { return table.Indices[i].Name < table.Indices[j].Name })
+
+	return table
+}
+
+// sqliteColumns reads the columns and the primary key of a SQLite table from pragma_table_info. xorm splits
+// the CREATE TABLE statement on commas instead, and reads table constraints such as a FOREIGN KEY as columns.
+func sqliteColumns(engine *xorm.Engine, tableName string) ([]*migrator.Column, []string, error) {
+	var createSQL string
+	if _, err := engine.SQL("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", tableName).Get(&createSQL); err != nil {
+		return nil, nil, fmt.Errorf("failed to read the definition of table %s: %w", tableName, err)
+	}
+	// only an INTEGER PRIMARY KEY can be AUTOINCREMENT
+	autoIncrement := strings.Contains(strings.ToUpper(createSQL), "AUTOINCREMENT")
+
+	rows, err := engine.DB().Query(`SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid`, tableName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the columns of table %s: %w", tableName, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns := make([]*migrator.Column, 0)
+	pks := make(map[int]string)
+	for rows.Next() {
+		var name, declaredType string
+		var notNull bool
+		var dflt sql.NullString
+		var pk int
+		if err := rows.Scan(&name, &declaredType, &notNull, &dflt, &pk); err != nil {
+			return nil, nil, err
+		}
+
+		col := &migrator.Column{
+			Name:         name,
+			Nullable:     !notNull,
+			IsPrimaryKey: pk > 0,
+			Default:      dflt.String,
+		}
+		col.Type, col.Length, col.Length2 = parseSQLiteType(declaredType)
+		col.IsAutoIncrement = col.IsPrimaryKey && autoIncrement
+		columns = append(columns, col)
+		if pk > 0 {
+			pks[pk] = name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	primaryKeys := make([]string, 0, len(pks))
+	for i := 1; i <= len(pks); i++ {
+		primaryKeys = append(primaryKeys, pks[i])
+	}
+	return columns, primaryKeys, nil
+}
+
+// parseSQLiteType splits a declared type such as VARCHAR(190) or DECIMAL(10, 2) into its name and lengths.
+func parseSQLiteType(declared string) (string, int, int) {
+	name, args, found := strings.Cut(declared, "(")
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if !found {
+		return name, 0, 0
+	}
+
+	lengths := strings.Split(strings.TrimSuffix(strings.TrimSpace(args), ")"), ",")
+	length, _ := strconv.Atoi(strings.TrimSpace(lengths[0]))
+	length2 := 0
+	if len(lengths) > 1 {
+		length2, _ = strconv.Atoi(strings.TrimSpace(lengths[1]))
+	}
+	return name, length, length2
+}
+
+func structName(bean interface{}) string {
+	t := reflect.TypeOf(bean)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.String()
+}
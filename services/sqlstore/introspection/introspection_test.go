@@ -0,0 +1,73 @@
+package introspection
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+	"xorm.io/xorm"
+)
+
+func TestInspectSQLiteSkipsTableConstraints(t *testing.T) {
+	engine, err := xorm.NewEngine(migrator.SQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = engine.Close()
+	})
+
+	for _, statement := range []string{
+		"CREATE TABLE `parent` (`id` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL)",
+		// columns named like the keywords of the table constraints are columns all the same
+		"CREATE TABLE `child` (\n" +
+			"`id` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,\n" +
+			"`parent_id` INTEGER NOT NULL,\n" +
+			"`on` VARCHAR(190) NULL DEFAULT 'x',\n" +
+			"`check` DECIMAL(10, 2) NOT NULL DEFAULT 0,\n" +
+			"CONSTRAINT `FK_child_parent` FOREIGN KEY (`parent_id`) REFERENCES `parent` (`id`) ON DELETE CASCADE,\n" +
+			"CONSTRAINT `CHK_child_check` CHECK (`check` >= 0 AND `on` IN ('x', 'y')),\n" +
+			"UNIQUE (`parent_id`, `on`))",
+	} {
+		if _, err := engine.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tables, err := Inspect(engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var child *migrator.Table
+	for _, table := range tables {
+		if table.Name == "child" {
+			child = table
+		}
+	}
+	if child == nil {
+		t.Fatal("table child not found")
+	}
+
+	want := []migrator.Column{
+		{Name: "id", Type: "INTEGER", Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+		{Name: "parent_id", Type: "INTEGER", Nullable: false},
+		{Name: "on", Type: "VARCHAR", Length: 190, Nullable: true, Default: "'x'"},
+		{Name: "check", Type: "DECIMAL", Length: 10, Length2: 2, Nullable: false, Default: "0"},
+	}
+	names := make([]string, 0, len(child.Columns))
+	for _, col := range child.Columns {
+		names = append(names, col.Name)
+	}
+	if len(child.Columns) != len(want) {
+		t.Fatalf("columns are %s, expected %d columns", strings.Join(names, ", "), len(want))
+	}
+	for i, col := range child.Columns {
+		if *col != want[i] {
+			t.Errorf("column %d is %+v, expected %+v", i, *col, want[i])
+		}
+	}
+	if strings.Join(child.PrimaryKeys, ",") != "id" {
+		t.Errorf("primary keys are %v, expected [id]", child.PrimaryKeys)
+	}
+}
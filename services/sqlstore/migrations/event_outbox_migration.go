@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	. "github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+)
+
+func addEventOutboxMigrations(mg *Migrator) {
+	eventOutboxV1 := Table{
+		Name: "event_outbox",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "event_type", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "payload", Type: DB_Text, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "delivered", Type: DB_Bool, Nullable: false, Default: "0"},
+			{Name: "delivered_at", Type: DB_DateTime, Nullable: true},
+			{Name: "attempts", Type: DB_Int, Nullable: false, Default: "0"},
+			{Name: "last_error", Type: DB_Text, Nullable: true},
+			{Name: "next_attempt_at", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"delivered", "id"}},
+		},
+	}
+
+	// create table
+	mg.AddMigration("create event_outbox table", NewAddTableMigration(eventOutboxV1))
+	// add indices
+	mg.AddMigration("add index event_outbox.delivered_id", NewAddIndexMigration(eventOutboxV1, eventOutboxV1.Indices[0]))
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	. "github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+)
+
+// LoginMigrations are the migrations of the login service, logged in login_migration_log.
+// They run after OxygenMigrations, which create the user table.
+type LoginMigrations struct {
+}
+
+func ProvideLoginMigrations() *LoginMigrations {
+	return &LoginMigrations{}
+}
+
+func (*LoginMigrations) Scope() string {
+	return "login"
+}
+
+func (*LoginMigrations) DependsOn() []string {
+	return nil
+}
+
+func (*LoginMigrations) AddMigration(mg *Migrator) {
+	userSessionV1 := Table{
+		Name: "user_session",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "token_hash", Type: DB_Char, Length: 64, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "expires_at", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"token_hash"}, Type: UniqueIndex},
+			{Cols: []string{"user_id"}},
+		},
+		// the sessions of a user are deleted with the user
+		ForeignKeys: []*ForeignKey{
+			{Cols: []string{"user_id"}, RefTable: "user", RefCols: []string{"id"}, OnDelete: Cascade},
+		},
+	}
+
+	// create table
+	mg.AddMigration("create user_session table", NewAddTableMigration(userSessionV1))
+	// add indices
+	mg.AddMigration("add unique index user_session.token_hash", NewAddIndexMigration(userSessionV1, userSessionV1.Indices[0]))
+	mg.AddMigration("add index user_session.user_id", NewAddIndexMigration(userSessionV1, userSessionV1.Indices[1]))
+}
@@ -12,4 +12,6 @@ func ProvideOSSMigrations() *OxygenMigrations {
 func (*OxygenMigrations) AddMigration(mg *Migrator) {
 	mg.AddCreateMigration()
 	addUserMigrations(mg)
+	addUserAuthMigrations(mg)
+	addEventOutboxMigrations(mg)
 }
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	. "github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+)
+
+func addUserAuthMigrations(mg *Migrator) {
+	userAuthV1 := Table{
+		Name: "user_auth",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "auth_module", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "auth_id", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"auth_module", "auth_id"}},
+			{Cols: []string{"user_id"}},
+		},
+	}
+
+	// create table
+	mg.AddMigration("create user_auth table", NewAddTableMigration(userAuthV1))
+	// add indices
+	mg.AddMigration("add index user_auth.auth_module_auth_id", NewAddIndexMigration(userAuthV1, userAuthV1.Indices[0]))
+	mg.AddMigration("add index user_auth.user_id", NewAddIndexMigration(userAuthV1, userAuthV1.Indices[1]))
+}
@@ -33,4 +33,32 @@ func addUserMigrations(mg *Migrator) {
 	// add indices
 	mg.AddMigration("add unique index user.login", NewAddIndexMigration(userV1, userV1.Indices[0]))
 	mg.AddMigration("add unique index user.email", NewAddIndexMigration(userV1, userV1.Indices[1]))
+
+	// Version 2: the columns the user service relies on
+	mg.AddMigration("add column email_verified to user", NewAddColumnMigration(userV1, &Column{
+		Name: "email_verified", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column theme to user", NewAddColumnMigration(userV1, &Column{
+		Name: "theme", Type: DB_NVarchar, Length: 255, Nullable: true,
+	}))
+	mg.AddMigration("add column help_flags1 to user", NewAddColumnMigration(userV1, &Column{
+		Name: "help_flags1", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column last_seen_at to user", NewAddColumnMigration(userV1, &Column{
+		Name: "last_seen_at", Type: DB_DateTime, Nullable: true,
+	}))
+	mg.AddMigration("add column is_disabled to user", NewAddColumnMigration(userV1, &Column{
+		Name: "is_disabled", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column is_service_account to user", NewAddColumnMigration(userV1, &Column{
+		Name: "is_service_account", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+
+	// the user table can be large, the indices are built without blocking the writes
+	mg.AddMigration("add index user.last_seen_at", NewAddIndexMigration(userV1, &Index{
+		Cols: []string{"last_seen_at"}, Concurrent: true,
+	}))
+	mg.AddMigration("add index user.is_service_account", NewAddIndexMigration(userV1, &Index{
+		Cols: []string{"is_service_account"}, Concurrent: true,
+	}))
 }
@@ -0,0 +1,176 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"xorm.io/xorm"
+)
+
+// backfillLogInterval is how often the progress of a backfill is logged
+const backfillLogInterval = 10 * time.Second
+
+// BackfillFunc processes the rows of a batch, those whose primary key is greater than from and at most to.
+// It runs in the transaction of the batch and runs again for the same rows if the batch fails, so it has to be idempotent.
+type BackfillFunc func(sess *xorm.Session, from, to int64) error
+
+// MigrationCheckpoint records how far a backfill got, so that it resumes after the last committed batch.
+type MigrationCheckpoint struct {
+	Id          int64
+	MigrationID string `xorm:"migration_id"`
+	LastKey     int64  `xorm:"last_key"`
+	Processed   int64
+	Updated     time.Time
+}
+
+// BackfillMigration processes the rows of a table in batches of Dialect.BatchSize, paginated by its integer
+// primary key. Every batch is committed on its own, together with a checkpoint the backfill resumes from
+// if the migrator stops before it is done. The progress is reported through the migrator logger.
+type BackfillMigration struct {
+	MigrationBase
+	tableName string
+	pk        string
+	backfill  BackfillFunc
+	statement func(d Dialect, where string) string
+	// syncTable is the table whose sequence is synced once the backfill is done, for statements inserting explicit pks
+	syncTable string
+}
+
+func NewBackfillMigration(table Table, backfill BackfillFunc) *BackfillMigration {
+	return &BackfillMigration{tableName: table.Name, pk: primaryKey(table), backfill: backfill}
+}
+
+// NewBackfillSQLMigration backfills with a statement executed for every batch, statement gets the condition
+// selecting the rows of the batch, which takes the bounds of the batch as arguments,
+// e.g. fmt.Sprintf("UPDATE %s SET %s = 0 WHERE %s", d.Quote("user"), d.Quote("help_flags1"), where).
+func NewBackfillSQLMigration(table Table, statement func(d Dialect, where string) string) *BackfillMigration {
+	return &BackfillMigration{tableName: table.Name, pk: primaryKey(table), statement: statement}
+}
+
+// where returns the condition selecting the rows of a batch, the pk is qualified for statements joining other tables
+func (m *BackfillMigration) where(d Dialect) string {
+	pk := d.Quote(m.tableName) + "." + d.Quote(m.pk)
+	return pk + " > ? AND " + pk + " <= ?"
+}
+
+// SQL returns the statement of the backfill, or the no-op SQL when it runs a BackfillFunc.
+func (m *BackfillMigration) SQL(d Dialect) string {
+	if m.statement == nil {
+		return d.NoOpSQL()
+	}
+	return m.statement(d, m.where(d))
+}
+
+// NoTransaction is always true, every batch runs in a transaction of its own.
+func (m *BackfillMigration) NoTransaction(_ Dialect) bool {
+	return true
+}
+
+func (m *BackfillMigration) Exec(sess *xorm.Session, mg *Migrator) error {
+	if m.pk == "" {
+		return fmt.Errorf("table %s has no primary key to backfill it in batches", m.tableName)
+	}
+
+	backfill := m.backfill
+	if backfill == nil {
+		statement := m.SQL(mg.Dialect)
+		backfill = func(sess *xorm.Session, from, to int64) error {
+			_, err := sess.Exec(statement, from, to)
+			return err
+		}
+	}
+
+	d := mg.Dialect
+	quote := d.Quote
+	pk := quote(m.pk)
+	nextSQL := fmt.Sprintf("SELECT %s FROM %s WHERE %s > ? ORDER BY %s", pk, quote(m.tableName), pk, pk) +
+		d.LimitOffset(1, int64(d.BatchSize()-1))
+	lastSQL := fmt.Sprintf("SELECT MAX(%s) FROM %s WHERE %s > ?", pk, quote(m.tableName), pk)
+	countSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s > ?", quote(m.tableName), pk)
+
+	var checkpoint MigrationCheckpoint
+	resumed, err := sess.Table(mg.checkpointTable).Where("migration_id = ?", m.Id()).Get(&checkpoint)
+	if err != nil {
+		return err
+	}
+	if !resumed {
+		checkpoint = MigrationCheckpoint{MigrationID: m.Id(), LastKey: math.MinInt64}
+	}
+	var remaining int64
+	if _, err := sess.SQL(countSQL, checkpoint.LastKey).Get(&remaining); err != nil {
+		return err
+	}
+	total := checkpoint.Processed + remaining
+	if resumed {
+		mg.Logger.Printf("%s: resuming after %s %d, %d of %d rows processed", m.Id(), m.pk, checkpoint.LastKey, checkpoint.Processed, total)
+	}
+
+	logged := time.Now()
+	for {
+		var to sql.NullInt64
+		full, err := sess.SQL(nextSQL, checkpoint.LastKey).Get(&to)
+		if err != nil {
+			return err
+		}
+		if !full {
+			// the last batch ends with the largest pk
+			if _, err := sess.SQL(lastSQL, checkpoint.LastKey).Get(&to); err != nil {
+				return err
+			}
+			if !to.Valid {
+				break
+			}
+		}
+
+		count := int64(d.BatchSize())
+		if !full {
+			if _, err := sess.SQL(countSQL+" AND "+pk+" <= ?", checkpoint.LastKey, to.Int64).Get(&count); err != nil {
+				return err
+			}
+		}
+		batch := checkpoint
+		batch.LastKey, batch.Processed, batch.Updated = to.Int64, checkpoint.Processed+count, time.Now()
+		err = mg.InTransaction(func(tx *xorm.Session) error {
+			if err := backfill(tx, checkpoint.LastKey, to.Int64); err != nil {
+				return err
+			}
+			return mg.saveCheckpoint(tx, &batch)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to backfill %s after %s %d: %w", m.tableName, m.pk, checkpoint.LastKey, err)
+		}
+		checkpoint = batch
+
+		if time.Since(logged) >= backfillLogInterval {
+			mg.Logger.Printf("%s: %d of %d rows processed, up to %s %d", m.Id(), checkpoint.Processed, total, m.pk, checkpoint.LastKey)
+			logged = time.Now()
+		}
+		if !full {
+			break
+		}
+	}
+	mg.Logger.Printf("%s: done, %d rows processed", m.Id(), checkpoint.Processed)
+
+	if m.syncTable != "" {
+		if sync := d.SyncSequenceSQL(m.syncTable, m.pk); sync != "" {
+			if _, err := sess.Exec(sync); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the checkpoint is not needed anymore once the backfill is done
+	_, err = sess.Table(mg.checkpointTable).Where("migration_id = ?", m.Id()).Delete(&MigrationCheckpoint{})
+	return err
+}
+
+func (mg *Migrator) saveCheckpoint(sess *xorm.Session, checkpoint *MigrationCheckpoint) error {
+	if checkpoint.Id == 0 {
+		_, err := sess.Table(mg.checkpointTable).Insert(checkpoint)
+		return err
+	}
+	_, err := sess.Table(mg.checkpointTable).ID(checkpoint.Id).Cols("last_key", "processed", "updated").Update(checkpoint)
+	return err
+}
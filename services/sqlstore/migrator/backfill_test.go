@@ -0,0 +1,80 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"xorm.io/xorm"
+)
+
+func TestBackfillResumesFromCheckpoint(t *testing.T) {
+	engine := newTestEngine(t)
+	batch := int64(NewDialect(SQLite).BatchSize())
+	// two full batches and a partial one
+	rows := 2*batch + 5
+
+	var batches []int64
+	fail := true
+	register := func(mg *Migrator) {
+		mg.AddMigration("create test_item table", NewAddTableMigration(testTable))
+		mg.AddMigration("insert test_item rows", NewRawSQLMigration(fmt.Sprintf(
+			"WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < %d) INSERT INTO test_item (id, name) SELECT i, 'todo' FROM n", rows)))
+		mg.AddMigration("backfill test_item.name", NewBackfillMigration(testTable, func(sess *xorm.Session, from, to int64) error {
+			batches = append(batches, from)
+			if fail && len(batches) == 2 {
+				return errors.New("interrupted")
+			}
+			_, err := sess.Exec("UPDATE test_item SET name = 'done' WHERE id > ? AND id <= ?", from, to)
+			return err
+		}))
+	}
+
+	mg, _ := newTestMigrator(engine, register)
+	if err := mg.Start(false, 0); err == nil || !strings.Contains(err.Error(), "interrupted") {
+		t.Fatalf("the first run returned %v, expected the error of the second batch", err)
+	}
+
+	var checkpoint MigrationCheckpoint
+	found, err := engine.Table(mg.checkpointTable).Where("migration_id = ?", "backfill test_item.name").Get(&checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || checkpoint.LastKey != batch || checkpoint.Processed != batch {
+		t.Fatalf("checkpoint is %+v (found %t), expected the first batch up to id %d", checkpoint, found, batch)
+	}
+	done, err := engine.Table("test_item").Where("name = 'done'").Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done != batch {
+		t.Errorf("%d rows are backfilled after the first run, expected the %d of the committed batch", done, batch)
+	}
+
+	fail, batches = false, nil
+	mg, logs := newTestMigrator(engine, register)
+	if err := mg.Start(false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{batch, 2 * batch}; fmt.Sprint(batches) != fmt.Sprint(want) {
+		t.Errorf("the second run processed the batches after %v, expected %v", batches, want)
+	}
+	if !strings.Contains(logs.String(), fmt.Sprintf("resuming after id %d, %d of %d rows processed", batch, batch, rows)) {
+		t.Errorf("the resume is not logged:\n%s", logs)
+	}
+	done, err = engine.Table("test_item").Where("name = 'done'").Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done != rows {
+		t.Errorf("%d rows are backfilled, expected %d", done, rows)
+	}
+	left, err := engine.Table(mg.checkpointTable).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left != 0 {
+		t.Errorf("%d checkpoints are left once the backfill is done", left)
+	}
+}
@@ -0,0 +1,129 @@
+package migrator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Checksum returns the checksum recorded in the migration log for the SQL of a migration.
+// Whitespace is normalized, so reformatting a migration does not count as a change.
+func Checksum(sql string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(sql), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ChangedMigration is an applied migration whose SQL is no longer the one that ran.
+type ChangedMigration struct {
+	// Scope is the scope of the migrator the migration is registered to, empty for the default one
+	Scope      string `json:"scope,omitempty"`
+	ID         string `json:"id"`
+	LoggedSQL  string `json:"loggedSql"`
+	CurrentSQL string `json:"currentSql"`
+}
+
+// DriftReport lists the differences between the migration log and the registered migrations.
+type DriftReport struct {
+	Changed []*ChangedMigration `json:"changed"`
+	// Missing are the ids of applied migrations that are not registered anymore,
+	// prefixed with the scope of scoped migrators, e.g. user/add column theme
+	Missing []string `json:"missing"`
+}
+
+// HasDrift is true when the report contains any difference.
+func (r *DriftReport) HasDrift() bool {
+	return len(r.Changed) > 0 || len(r.Missing) > 0
+}
+
+// Verify compares the applied migrations with the registered ones. Entries logged before
+// checksums were recorded are compared using the checksum of their logged SQL.
+func (mg *Migrator) Verify() (*DriftReport, error) {
+	logMap, err := mg.GetMigrationLog()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DriftReport{
+		Changed: make([]*ChangedMigration, 0),
+		Missing: make([]string, 0),
+	}
+	for _, m := range mg.migrations {
+		logItem, applied := logMap[m.Id()]
+		if !applied || m.SkipMigrationLog() {
+			continue
+		}
+
+		logged := logItem.Checksum
+		if logged == "" {
+			logged = Checksum(logItem.SQL)
+		}
+		if sql := m.SQL(mg.Dialect); Checksum(sql) != logged {
+			report.Changed = append(report.Changed, &ChangedMigration{
+				Scope:      mg.scope,
+				ID:         m.Id(),
+				LoggedSQL:  logItem.SQL,
+				CurrentSQL: sql,
+			})
+		}
+	}
+
+	for id := range logMap {
+		if _, ok := mg.migrationIds[id]; !ok {
+			report.Missing = append(report.Missing, scopedID(mg.scope, id))
+		}
+	}
+	sort.Strings(report.Missing)
+
+	return report, nil
+}
+
+// Merge appends the differences of a report of another scope.
+func (r *DriftReport) Merge(other *DriftReport) {
+	r.Changed = append(r.Changed, other.Changed...)
+	r.Missing = append(r.Missing, other.Missing...)
+}
+
+// checkDrift logs the drift found by Verify, it only fails when FailOnDrift is set.
+func (mg *Migrator) checkDrift() error {
+	report, err := mg.Verify()
+	if err != nil {
+		return err
+	}
+	if !report.HasDrift() {
+		return nil
+	}
+
+	for _, m := range report.Changed {
+		mg.Logger.Printf("migration %q changed after it was applied", m.ID)
+	}
+	for _, id := range report.Missing {
+		mg.Logger.Printf("migration %q is applied but not registered", id)
+	}
+
+	if mg.FailOnDrift {
+		return fmt.Errorf("%w: %d changed, %d missing", ErrMigrationDrift, len(report.Changed), len(report.Missing))
+	}
+	return nil
+}
+
+// WriteText writes the report with the logged and current SQL of every changed migration.
+func (r *DriftReport) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	if !r.HasDrift() {
+		sb.WriteString("no drift, the applied migrations match the code\n")
+	}
+	for _, m := range r.Changed {
+		fmt.Fprintf(&sb, "changed: %s\n", scopedID(m.Scope, m.ID))
+		fmt.Fprintf(&sb, "  logged:  %s\n", strings.Join(strings.Fields(m.LoggedSQL), " "))
+		fmt.Fprintf(&sb, "  current: %s\n", strings.Join(strings.Fields(m.CurrentSQL), " "))
+	}
+	for _, id := range r.Missing {
+		fmt.Fprintf(&sb, "missing: %s\n", id)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
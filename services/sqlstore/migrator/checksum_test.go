@@ -0,0 +1,90 @@
+package migrator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckDrift(t *testing.T) {
+	const indexSQL = "CREATE INDEX IDX_test_item_name ON test_item (name)"
+
+	applied := func(t *testing.T) *Migrator {
+		engine := newTestEngine(t)
+		mg, _ := newTestMigrator(engine, func(mg *Migrator) {
+			mg.AddMigration("create test_item table", NewAddTableMigration(testTable))
+			mg.AddMigration("add index test_item.name", NewRawSQLMigration(indexSQL))
+		})
+		if err := mg.Start(false, 0); err != nil {
+			t.Fatal(err)
+		}
+		return mg
+	}
+
+	t.Run("unchanged migrations", func(t *testing.T) {
+		mg := applied(t)
+		again, logs := newTestMigrator(mg.DBEngine, func(again *Migrator) {
+			again.AddMigration("create test_item table", NewAddTableMigration(testTable))
+			// reformatting is not a change
+			again.AddMigration("add index test_item.name", NewRawSQLMigration("CREATE INDEX IDX_test_item_name\n\tON test_item (name)"))
+		})
+		again.FailOnDrift = true
+		if err := again.checkDrift(); err != nil {
+			t.Fatalf("checkDrift failed without drift: %v", err)
+		}
+		if logs.Len() > 0 {
+			t.Errorf("checkDrift logged without drift: %s", logs)
+		}
+	})
+
+	t.Run("changed and missing migrations", func(t *testing.T) {
+		mg := applied(t)
+		again, logs := newTestMigrator(mg.DBEngine, func(again *Migrator) {
+			again.AddMigration("add index test_item.name", NewRawSQLMigration("CREATE UNIQUE INDEX IDX_test_item_name ON test_item (name)"))
+		})
+
+		// the drift is only logged by default
+		if err := again.checkDrift(); err != nil {
+			t.Fatalf("checkDrift failed without FailOnDrift: %v", err)
+		}
+		for _, want := range []string{
+			`migration "add index test_item.name" changed after it was applied`,
+			`migration "create test_item table" is applied but not registered`,
+		} {
+			if !strings.Contains(logs.String(), want) {
+				t.Errorf("checkDrift did not log %q, it logged: %s", want, logs)
+			}
+		}
+
+		again.FailOnDrift = true
+		err := again.checkDrift()
+		if !errors.Is(err, ErrMigrationDrift) {
+			t.Fatalf("checkDrift returned %v, expected ErrMigrationDrift", err)
+		}
+		if !strings.Contains(err.Error(), "1 changed, 1 missing") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("migrations logged before checksums", func(t *testing.T) {
+		mg := applied(t)
+		if _, err := mg.DBEngine.Exec("UPDATE migration_log SET checksum = NULL"); err != nil {
+			t.Fatal(err)
+		}
+
+		again, _ := newTestMigrator(mg.DBEngine, func(again *Migrator) {
+			again.AddMigration("create test_item table", NewAddTableMigration(testTable))
+			again.AddMigration("add index test_item.name", NewRawSQLMigration(indexSQL+" WHERE name IS NOT NULL"))
+		})
+		report, err := again.Verify()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Changed) != 1 || report.Changed[0].ID != "add index test_item.name" {
+			t.Fatalf("expected only the index migration to have changed, got %+v", report.Changed)
+		}
+		if report.Changed[0].LoggedSQL != indexSQL {
+			t.Errorf("logged SQL is %q, expected %q", report.Changed[0].LoggedSQL, indexSQL)
+		}
+	})
+}
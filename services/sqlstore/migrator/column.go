@@ -13,6 +13,8 @@ type Column struct {
 	IsAutoIncrement bool
 	IsLatin         bool
 	Default         string
+	// Comment is set on MySQL and Postgres, sqlite has no comments
+	Comment string
 }
 
 func (col *Column) String(d Dialect) string {
@@ -37,6 +37,16 @@ func (c *IfIndexNotExistsCondition) SQL(dialect Dialect) (string, []interface{})
 	return dialect.IndexCheckSQL(c.TableName, c.IndexName)
 }
 
+type IfColumnExistsCondition struct {
+	ExistsMigrationCondition
+	TableName  string
+	ColumnName string
+}
+
+func (c *IfColumnExistsCondition) SQL(dialect Dialect) (string, []interface{}) {
+	return dialect.ColumnCheckSQL(c.TableName, c.ColumnName)
+}
+
 type IfColumnNotExistsCondition struct {
 	NotExistsMigrationCondition
 	TableName  string
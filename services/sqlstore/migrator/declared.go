@@ -0,0 +1,123 @@
+package migrator
+
+// DeclaredTables replays the registered migrations and returns the tables they declare, as they look
+// once every migration is applied. Raw SQL and other code migrations are not interpreted.
+func (mg *Migrator) DeclaredTables() []*Table {
+	tables := make([]*Table, 0)
+	find := func(name string) *Table {
+		for _, t := range tables {
+			if t.Name == name {
+				return t
+			}
+		}
+		return nil
+	}
+
+	for _, m := range mg.migrations {
+		switch m := m.(type) {
+		case *AddTableMigration:
+			t := &Table{Name: m.table.Name, PrimaryKeys: append([]string{}, m.table.PrimaryKeys...), Comment: m.table.Comment}
+			for _, col := range m.table.Columns {
+				c := *col
+				t.Columns = append(t.Columns, &c)
+			}
+			t.ForeignKeys = append(t.ForeignKeys, m.table.ForeignKeys...)
+			t.Checks = append(t.Checks, m.table.Checks...)
+			tables = append(tables, t)
+		case *DropTableMigration:
+			for i, t := range tables {
+				if t.Name == m.tableName {
+					tables = append(tables[:i], tables[i+1:]...)
+					break
+				}
+			}
+		case *RenameTableMigration:
+			if t := find(m.oldName); t != nil {
+				t.Name = m.newName
+			}
+		case *AddColumnMigration:
+			if t := find(m.tableName); t != nil && t.column(m.column.Name) == nil {
+				c := *m.column
+				t.Columns = append(t.Columns, &c)
+			}
+		case *DropColumnMigration:
+			if t := find(m.tableName); t != nil {
+				for i, col := range t.Columns {
+					if col.Name == m.column.Name {
+						t.Columns = append(t.Columns[:i], t.Columns[i+1:]...)
+						break
+					}
+				}
+			}
+		case *AlterColumnMigration:
+			if t := find(m.tableName); t != nil {
+				if c := t.column(m.column.Name); c != nil {
+					*c = *m.column
+				}
+			}
+		case *RenameColumnMigration:
+			if t := find(m.table.Name); t != nil {
+				if c := t.column(m.column.Name); c != nil {
+					c.Name = m.newName
+				}
+			}
+		case *AddIndexMigration:
+			if t := find(m.tableName); t != nil {
+				idx := *m.index
+				idx.Cols = append([]string{}, m.index.Cols...)
+				t.Indices = append(t.Indices, &idx)
+			}
+		case *DropIndexMigration:
+			if t := find(m.tableName); t != nil {
+				dropped := (&Index{Name: m.index.Name, Type: m.index.Type, Cols: m.index.Cols}).XName(m.tableName)
+				for i, idx := range t.Indices {
+					if idx.XName(t.Name) == dropped {
+						t.Indices = append(t.Indices[:i], t.Indices[i+1:]...)
+						break
+					}
+				}
+			}
+		case *AddConstraintMigration:
+			if t := find(m.tableName); t != nil {
+				if m.fk != nil {
+					t.ForeignKeys = append(t.ForeignKeys, m.fk)
+				} else {
+					t.Checks = append(t.Checks, m.check)
+				}
+			}
+		case *DropConstraintMigration:
+			if t := find(m.tableName); t != nil {
+				t.dropConstraint(m.fk, m.check)
+			}
+		}
+	}
+
+	return tables
+}
+
+func (t *Table) column(name string) *Column {
+	for _, col := range t.Columns {
+		if col.Name == name {
+			return col
+		}
+	}
+	return nil
+}
+
+func (t *Table) dropConstraint(fk *ForeignKey, check *Check) {
+	if fk != nil {
+		for i, declared := range t.ForeignKeys {
+			if declared.XName(t.Name) == fk.XName(t.Name) {
+				t.ForeignKeys = append(t.ForeignKeys[:i], t.ForeignKeys[i+1:]...)
+				return
+			}
+		}
+		return
+	}
+	for i, declared := range t.Checks {
+		if declared.XName(t.Name) == check.XName(t.Name) {
+			t.Checks = append(t.Checks[:i], t.Checks[i+1:]...)
+			return
+		}
+	}
+}
@@ -34,10 +34,33 @@ type Dialect interface {
 	CreateIndexSQL(tableName string, index *Index) string
 	CreateTableSQL(table *Table) string
 	AddColumnSQL(tableName string, col *Column) string
+	DropColumnSQL(tableName string, columnName string) string
+	// AlterColumnSQL changes the type, nullability, default and comment of a column to the ones of col,
+	// it returns an empty string when the dialect cannot alter columns and the table has to be recreated
+	AlterColumnSQL(tableName string, col *Column) string
 	CopyTableData(sourceTable string, targetTable string, sourceCols []string, targetCols []string) string
 	DropTable(tableName string) string
 	DropIndexSQL(tableName string, index *Index) string
 
+	// ForeignKeySQL and CheckSQL return the constraint definitions used by CreateTableSQL and AddConstraintSQL
+	ForeignKeySQL(tableName string, fk *ForeignKey) string
+	CheckSQL(tableName string, check *Check) string
+	// AddConstraintSQL, DropForeignKeySQL and DropCheckSQL return an empty string when
+	// the dialect cannot alter the constraints of a table, which then has to be recreated
+	AddConstraintSQL(tableName string, definition string) string
+	DropForeignKeySQL(tableName string, fk *ForeignKey) string
+	DropCheckSQL(tableName string, check *Check) string
+
+	// CopyColumnTriggerSQL returns the statements creating the triggers that copy what is written to column from
+	// into column to, CopyRowsTriggerSQL the ones applying the inserts, updates and deletes of a table to targetTable.
+	// DropTriggerSQL drops the triggers created by either under triggerName.
+	CopyColumnTriggerSQL(tableName, triggerName, from, to string) []string
+	CopyRowsTriggerSQL(tableName, triggerName, targetTable string, cols []string, pk string) []string
+	DropTriggerSQL(tableName, triggerName string) []string
+	// SyncSequenceSQL returns the statement making the auto increment of a table continue after the largest pk,
+	// it is empty when inserting explicit values already does it
+	SyncSequenceSQL(tableName, pk string) string
+
 	// RenameTable is deprecated, its use cause breaking changes
 	// so, it should no longer be used. Kept for legacy reasons.
 	RenameTable(oldName string, newName string) string
@@ -48,6 +71,9 @@ type Dialect interface {
 	UpdateTableSQL(tableName string, columns []*Column) string
 
 	IndexCheckSQL(tableName, indexName string) (string, []interface{})
+	// InvalidIndexCheckSQL returns the query finding an index left invalid by a failed concurrent build,
+	// an empty string if the dialect cannot leave such indexes behind
+	InvalidIndexCheckSQL(tableName, indexName string) (string, []interface{})
 	ColumnCheckSQL(tableName, columnName string) (string, []interface{})
 	// UpsertSQL returns the upsert sql statement for a dialect
 	UpsertSQL(tableName string, keyCols, updateCols []string) string
@@ -69,8 +95,13 @@ type Dialect interface {
 	IsUniqueConstraintViolation(err error) bool
 	ErrorMessage(err error) string
 	IsDeadlock(err error) bool
+	// IsRetryableError returns true for the errors after which the whole transaction
+	// can be run again, e.g. deadlocks or serialization failures
+	IsRetryableError(err error) bool
 	Lock(LockCfg) error
 	Unlock(LockCfg) error
+	// LockHolder describes the session holding the lock, it returns an empty string if it is unknown
+	LockHolder(LockCfg) (string, error)
 
 	GetDBName(string) (string, error)
 }
@@ -78,6 +109,7 @@ type Dialect interface {
 type LockCfg struct {
 	Session *xorm.Session
 	Key     string
+	// Timeout is how many seconds a single Lock call may wait for the lock, dialects without waiting ignore it
 	Timeout int
 }
 
@@ -86,6 +118,10 @@ type dialectFunc func() Dialect
 var supportedDialects = map[string]dialectFunc{
 	Postgres:               NewPostgresDialect,
 	Postgres + "WithHooks": NewPostgresDialect,
+	SQLite:                 NewSQLite3Dialect,
+	SQLite + "WithHooks":   NewSQLite3Dialect,
+	MySQL:                  NewMysqlDialect,
+	MySQL + "WithHooks":    NewMysqlDialect,
 }
 
 func NewDialect(driverName string) Dialect {
@@ -166,6 +202,13 @@ func (b *BaseDialect) CreateTableSQL(table *Table) string {
 		sql += "PRIMARY KEY ( " + strings.Join(quotedCols, ",") + " ), "
 	}
 
+	for _, fk := range table.ForeignKeys {
+		sql += b.dialect.ForeignKeySQL(table.Name, fk) + "\n, "
+	}
+	for _, check := range table.Checks {
+		sql += b.dialect.CheckSQL(table.Name, check) + "\n, "
+	}
+
 	sql = sql[:len(sql)-2] + ")"
 	if b.dialect.SupportEngine() {
 		sql += " ENGINE=InnoDB DEFAULT CHARSET utf8mb4 COLLATE utf8mb4_unicode_ci"
@@ -179,6 +222,14 @@ func (b *BaseDialect) AddColumnSQL(tableName string, col *Column) string {
 	return fmt.Sprintf("alter table %s ADD COLUMN %s", b.dialect.Quote(tableName), col.StringNoPk(b.dialect))
 }
 
+func (b *BaseDialect) DropColumnSQL(tableName string, columnName string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", b.dialect.Quote(tableName), b.dialect.Quote(columnName))
+}
+
+func (b *BaseDialect) AlterColumnSQL(tableName string, col *Column) string {
+	return ""
+}
+
 func (b *BaseDialect) CreateIndexSQL(tableName string, index *Index) string {
 	quote := b.dialect.Quote
 	var unique string
@@ -193,7 +244,77 @@ func (b *BaseDialect) CreateIndexSQL(tableName string, index *Index) string {
 		quotedCols = append(quotedCols, b.dialect.Quote(col))
 	}
 
-	return fmt.Sprintf("CREATE%s INDEX %v ON %v (%v);", unique, quote(idxName), quote(tableName), strings.Join(quotedCols, ","))
+	var where string
+	if index.Where != "" {
+		where = " WHERE " + index.Where
+	}
+
+	return fmt.Sprintf("CREATE%s INDEX %v ON %v (%v)%s;", unique, quote(idxName), quote(tableName), strings.Join(quotedCols, ","), where)
+}
+
+func (b *BaseDialect) ForeignKeySQL(tableName string, fk *ForeignKey) string {
+	quote := b.dialect.Quote
+	quoteCols := func(cols []string) string {
+		quoted := make([]string, 0, len(cols))
+		for _, col := range cols {
+			quoted = append(quoted, quote(col))
+		}
+		return strings.Join(quoted, ", ")
+	}
+
+	sql := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		quote(fk.XName(tableName)), quoteCols(fk.Cols), quote(fk.RefTable), quoteCols(fk.RefCols))
+	if fk.OnDelete != "" {
+		sql += " ON DELETE " + fk.OnDelete
+	}
+	return sql
+}
+
+func (b *BaseDialect) CheckSQL(tableName string, check *Check) string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", b.dialect.Quote(check.XName(tableName)), check.Expr)
+}
+
+func (b *BaseDialect) AddConstraintSQL(tableName string, definition string) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD %s", b.dialect.Quote(tableName), definition)
+}
+
+func (b *BaseDialect) DropForeignKeySQL(tableName string, fk *ForeignKey) string {
+	quote := b.dialect.Quote
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", quote(tableName), quote(fk.XName(tableName)))
+}
+
+func (b *BaseDialect) DropCheckSQL(tableName string, check *Check) string {
+	quote := b.dialect.Quote
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", quote(tableName), quote(check.XName(tableName)))
+}
+
+// triggerSuffixes are appended to the trigger name by the dialects that create a trigger per event
+var triggerSuffixes = []string{"_insert", "_update", "_delete"}
+
+func (b *BaseDialect) DropTriggerSQL(tableName, triggerName string) []string {
+	statements := make([]string, 0, len(triggerSuffixes))
+	for _, suffix := range triggerSuffixes {
+		statements = append(statements, "DROP TRIGGER IF EXISTS "+b.dialect.Quote(triggerName+suffix))
+	}
+	return statements
+}
+
+func (b *BaseDialect) SyncSequenceSQL(tableName, pk string) string {
+	return ""
+}
+
+// newValues returns the columns of the row written by a trigger, e.g. NEW.`id`
+func newValues(d Dialect, cols []string) string {
+	values := make([]string, 0, len(cols))
+	for _, col := range cols {
+		values = append(values, "NEW."+d.Quote(col))
+	}
+	return strings.Join(values, ", ")
+}
+
+// quoteString quotes a string literal, e.g. a comment
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
 }
 
 func (b *BaseDialect) QuoteColList(cols []string) string {
@@ -235,6 +356,10 @@ func (b *BaseDialect) ColumnCheckSQL(tableName, columnName string) (string, []in
 	return "", nil
 }
 
+func (b *BaseDialect) InvalidIndexCheckSQL(tableName, indexName string) (string, []interface{}) {
+	return "", nil
+}
+
 func (b *BaseDialect) DropIndexSQL(tableName string, index *Index) string {
 	quote := b.dialect.Quote
 	name := index.XName(tableName)
@@ -325,6 +450,10 @@ func (b *BaseDialect) UpsertSQL(tableName string, keyCols, updateCols []string)
 	return ""
 }
 
+func (b *BaseDialect) IsRetryableError(err error) bool {
+	return b.dialect.IsDeadlock(err)
+}
+
 func (b *BaseDialect) Lock(_ LockCfg) error {
 	return nil
 }
@@ -333,6 +462,10 @@ func (b *BaseDialect) Unlock(_ LockCfg) error {
 	return nil
 }
 
+func (b *BaseDialect) LockHolder(_ LockCfg) (string, error) {
+	return "", nil
+}
+
 func (b *BaseDialect) OrderBy(order string) string {
 	return order
 }
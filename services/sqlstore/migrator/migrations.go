@@ -1,7 +1,10 @@
 package migrator
 
 import (
+	"fmt"
 	"strings"
+
+	"xorm.io/xorm"
 )
 
 type MigrationBase struct {
@@ -28,7 +31,9 @@ func (m *MigrationBase) SkipMigrationLog() bool {
 type RawSQLMigration struct {
 	MigrationBase
 
-	sql map[string]string
+	sql           map[string]string
+	downSQL       map[string]string
+	noTransaction bool
 }
 
 // NewRawSQLMigration should be used carefully, the usage
@@ -56,6 +61,16 @@ func (m *RawSQLMigration) SQL(dialect Dialect) string {
 	return dialect.NoOpSQL()
 }
 
+// WithoutTransaction makes the migration run outside of a transaction for every dialect.
+func (m *RawSQLMigration) WithoutTransaction() *RawSQLMigration {
+	m.noTransaction = true
+	return m
+}
+
+func (m *RawSQLMigration) NoTransaction(_ Dialect) bool {
+	return m.noTransaction
+}
+
 func (m *RawSQLMigration) Set(dialect string, sql string) *RawSQLMigration {
 	if m.sql == nil {
 		m.sql = make(map[string]string)
@@ -73,6 +88,42 @@ func (m *RawSQLMigration) Postgres(sql string) *RawSQLMigration {
 	return m.Set(Postgres, sql)
 }
 
+func (m *RawSQLMigration) SQLite(sql string) *RawSQLMigration {
+	return m.Set(SQLite, sql)
+}
+
+func (m *RawSQLMigration) Mysql(sql string) *RawSQLMigration {
+	return m.Set(MySQL, sql)
+}
+
+// DownSQL returns the sql reverting the migration, it is empty unless SetDown or Down have been called.
+func (m *RawSQLMigration) DownSQL(dialect Dialect) string {
+	if m.downSQL != nil {
+		if val := m.downSQL[dialect.DriverName()]; val != "" {
+			return val
+		}
+
+		if val := m.downSQL["default"]; val != "" {
+			return val
+		}
+	}
+
+	return ""
+}
+
+func (m *RawSQLMigration) SetDown(dialect string, sql string) *RawSQLMigration {
+	if m.downSQL == nil {
+		m.downSQL = make(map[string]string)
+	}
+
+	m.downSQL[dialect] = sql
+	return m
+}
+
+func (m *RawSQLMigration) Down(sql string) *RawSQLMigration {
+	return m.SetDown("default", sql)
+}
+
 type AddColumnMigration struct {
 	MigrationBase
 	tableName string
@@ -99,6 +150,99 @@ func (m *AddColumnMigration) SQL(dialect Dialect) string {
 	return dialect.AddColumnSQL(m.tableName, m.column)
 }
 
+func (m *AddColumnMigration) DownSQL(dialect Dialect) string {
+	return dialect.DropColumnSQL(m.tableName, m.column.Name)
+}
+
+// DropColumnMigration drops a column, the column is needed to revert it, which restores the column but not its data.
+type DropColumnMigration struct {
+	MigrationBase
+	tableName string
+	column    *Column
+}
+
+func NewDropColumnMigration(table Table, col *Column) *DropColumnMigration {
+	m := &DropColumnMigration{tableName: table.Name, column: col}
+	m.Condition = &IfColumnExistsCondition{TableName: table.Name, ColumnName: col.Name}
+	return m
+}
+
+func (m *DropColumnMigration) SQL(dialect Dialect) string {
+	return dialect.DropColumnSQL(m.tableName, m.column.Name)
+}
+
+func (m *DropColumnMigration) DownSQL(dialect Dialect) string {
+	return dialect.AddColumnSQL(m.tableName, m.column)
+}
+
+// AlterColumnMigration changes the type, nullability, default or comment of a column to the ones of the given column.
+// Sqlite cannot alter columns, there the table is recreated outside of a transaction.
+type AlterColumnMigration struct {
+	MigrationBase
+	tableName string
+	column    *Column
+	previous  *Column
+}
+
+func NewAlterColumnMigration(table Table, col *Column) *AlterColumnMigration {
+	m := &AlterColumnMigration{tableName: table.Name, column: col}
+	m.Condition = &IfColumnExistsCondition{TableName: table.Name, ColumnName: col.Name}
+	return m
+}
+
+// From sets the column as it was before the migration, which is needed to revert it.
+func (m *AlterColumnMigration) From(previous *Column) *AlterColumnMigration {
+	m.previous = previous
+	return m
+}
+
+func (m *AlterColumnMigration) SQL(dialect Dialect) string {
+	if sql := dialect.AlterColumnSQL(m.tableName, m.column); sql != "" {
+		return sql
+	}
+	return dialect.NoOpSQL()
+}
+
+// DownSQL returns the sql reverting the migration, it is empty unless From has been called.
+func (m *AlterColumnMigration) DownSQL(dialect Dialect) string {
+	if m.previous == nil {
+		return ""
+	}
+	if sql := dialect.AlterColumnSQL(m.tableName, m.previous); sql != "" {
+		return sql
+	}
+	return dialect.NoOpSQL()
+}
+
+func (m *AlterColumnMigration) NoTransaction(dialect Dialect) bool {
+	return dialect.DriverName() == SQLite
+}
+
+func (m *AlterColumnMigration) Exec(sess *xorm.Session, mg *Migrator) error {
+	return alterColumn(sess, mg, m.tableName, m.column)
+}
+
+func (m *AlterColumnMigration) Down(sess *xorm.Session, mg *Migrator) error {
+	if m.previous == nil {
+		return fmt.Errorf("migration %s cannot be reverted, the previous column is not set", m.Id())
+	}
+	return alterColumn(sess, mg, m.tableName, m.previous)
+}
+
+func alterColumn(sess *xorm.Session, mg *Migrator, tableName string, col *Column) error {
+	sql := mg.Dialect.AlterColumnSQL(tableName, col)
+	if sql != "" {
+		_, err := sess.Exec(sql)
+		return err
+	}
+
+	definition := strings.TrimSpace(col.StringNoPk(mg.Dialect))
+	if col.IsPrimaryKey {
+		definition = strings.TrimSpace(col.String(mg.Dialect))
+	}
+	return recreateSQLiteTable(mg.DBEngine, tableName, sqliteAlterColumn(col.Name, definition))
+}
+
 type RenameColumnMigration struct {
 	MigrationBase
 	table   Table
@@ -152,6 +296,15 @@ func (m *AddIndexMigration) SQL(dialect Dialect) string {
 	return dialect.CreateIndexSQL(m.tableName, m.index)
 }
 
+func (m *AddIndexMigration) DownSQL(dialect Dialect) string {
+	return dialect.DropIndexSQL(m.tableName, m.index)
+}
+
+// NoTransaction is true for concurrent indexes on Postgres, CREATE INDEX CONCURRENTLY cannot run in a transaction.
+func (m *AddIndexMigration) NoTransaction(dialect Dialect) bool {
+	return m.index.Concurrent && dialect.DriverName() == Postgres
+}
+
 type DropIndexMigration struct {
 	MigrationBase
 	tableName string
@@ -171,6 +324,15 @@ func (m *DropIndexMigration) SQL(dialect Dialect) string {
 	return dialect.DropIndexSQL(m.tableName, m.index)
 }
 
+func (m *DropIndexMigration) DownSQL(dialect Dialect) string {
+	return dialect.CreateIndexSQL(m.tableName, m.index)
+}
+
+// NoTransaction is true for concurrent indexes on Postgres, DROP INDEX CONCURRENTLY cannot run in a transaction.
+func (m *DropIndexMigration) NoTransaction(dialect Dialect) bool {
+	return m.index.Concurrent && dialect.DriverName() == Postgres
+}
+
 type AddTableMigration struct {
 	MigrationBase
 	table Table
@@ -189,6 +351,10 @@ func (m *AddTableMigration) SQL(d Dialect) string {
 	return d.CreateTableSQL(&m.table)
 }
 
+func (m *AddTableMigration) DownSQL(d Dialect) string {
+	return d.DropTable(m.table.Name)
+}
+
 type DropTableMigration struct {
 	MigrationBase
 	tableName string
@@ -259,3 +425,117 @@ func NewTableCharsetMigration(tableName string, columns []*Column) *TableCharset
 func (m *TableCharsetMigration) SQL(d Dialect) string {
 	return d.UpdateTableSQL(m.tableName, m.columns)
 }
+
+// AddConstraintMigration adds a foreign key or a check constraint to an existing table.
+// Sqlite cannot alter the constraints of a table, there the table is recreated outside of a transaction.
+type AddConstraintMigration struct {
+	MigrationBase
+	tableName string
+	fk        *ForeignKey
+	check     *Check
+}
+
+func NewAddForeignKeyMigration(table Table, fk *ForeignKey) *AddConstraintMigration {
+	return &AddConstraintMigration{tableName: table.Name, fk: fk}
+}
+
+func NewAddCheckMigration(table Table, check *Check) *AddConstraintMigration {
+	return &AddConstraintMigration{tableName: table.Name, check: check}
+}
+
+func (m *AddConstraintMigration) SQL(d Dialect) string {
+	return constraintSQL(d, m.tableName, m.fk, m.check, true)
+}
+
+func (m *AddConstraintMigration) DownSQL(d Dialect) string {
+	return constraintSQL(d, m.tableName, m.fk, m.check, false)
+}
+
+func (m *AddConstraintMigration) NoTransaction(d Dialect) bool {
+	return d.DriverName() == SQLite
+}
+
+func (m *AddConstraintMigration) Exec(sess *xorm.Session, mg *Migrator) error {
+	return execConstraint(sess, mg, m.tableName, m.fk, m.check, true)
+}
+
+func (m *AddConstraintMigration) Down(sess *xorm.Session, mg *Migrator) error {
+	return execConstraint(sess, mg, m.tableName, m.fk, m.check, false)
+}
+
+// DropConstraintMigration drops a foreign key or a check constraint, the constraint is needed to revert it.
+type DropConstraintMigration struct {
+	MigrationBase
+	tableName string
+	fk        *ForeignKey
+	check     *Check
+}
+
+func NewDropForeignKeyMigration(table Table, fk *ForeignKey) *DropConstraintMigration {
+	return &DropConstraintMigration{tableName: table.Name, fk: fk}
+}
+
+func NewDropCheckMigration(table Table, check *Check) *DropConstraintMigration {
+	return &DropConstraintMigration{tableName: table.Name, check: check}
+}
+
+func (m *DropConstraintMigration) SQL(d Dialect) string {
+	return constraintSQL(d, m.tableName, m.fk, m.check, false)
+}
+
+func (m *DropConstraintMigration) DownSQL(d Dialect) string {
+	return constraintSQL(d, m.tableName, m.fk, m.check, true)
+}
+
+func (m *DropConstraintMigration) NoTransaction(d Dialect) bool {
+	return d.DriverName() == SQLite
+}
+
+func (m *DropConstraintMigration) Exec(sess *xorm.Session, mg *Migrator) error {
+	return execConstraint(sess, mg, m.tableName, m.fk, m.check, false)
+}
+
+func (m *DropConstraintMigration) Down(sess *xorm.Session, mg *Migrator) error {
+	return execConstraint(sess, mg, m.tableName, m.fk, m.check, true)
+}
+
+// constraintSQL returns the statement adding or dropping either fk or check, or the no-op SQL
+// for sqlite, where the table is recreated instead.
+func constraintSQL(d Dialect, tableName string, fk *ForeignKey, check *Check, add bool) string {
+	var sql string
+	switch {
+	case add && fk != nil:
+		sql = d.AddConstraintSQL(tableName, d.ForeignKeySQL(tableName, fk))
+	case add:
+		sql = d.AddConstraintSQL(tableName, d.CheckSQL(tableName, check))
+	case fk != nil:
+		sql = d.DropForeignKeySQL(tableName, fk)
+	default:
+		sql = d.DropCheckSQL(tableName, check)
+	}
+
+	if sql == "" {
+		return d.NoOpSQL()
+	}
+	return sql
+}
+
+func execConstraint(sess *xorm.Session, mg *Migrator, tableName string, fk *ForeignKey, check *Check, add bool) error {
+	if mg.Dialect.DriverName() != SQLite {
+		_, err := sess.Exec(constraintSQL(mg.Dialect, tableName, fk, check, add))
+		return err
+	}
+
+	var rewrite func(string) (string, error)
+	switch {
+	case add && fk != nil:
+		rewrite = sqliteAddConstraint(mg.Dialect.ForeignKeySQL(tableName, fk))
+	case add:
+		rewrite = sqliteAddConstraint(mg.Dialect.CheckSQL(tableName, check))
+	case fk != nil:
+		rewrite = sqliteDropConstraint(fk.XName(tableName))
+	default:
+		rewrite = sqliteDropConstraint(check.XName(tableName))
+	}
+	return recreateSQLiteTable(mg.DBEngine, tableName, rewrite)
+}
@@ -1,6 +1,8 @@
 package migrator
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4/database"
 	"log"
@@ -14,8 +16,19 @@ import (
 )
 
 var (
-	ErrMigratorIsLocked   = fmt.Errorf("migrator is locked")
-	ErrMigratorIsUnlocked = fmt.Errorf("migrator is unlocked")
+	ErrMigratorIsLocked      = fmt.Errorf("migrator is locked")
+	ErrMigratorIsUnlocked    = fmt.Errorf("migrator is unlocked")
+	ErrMigrationNotFound     = fmt.Errorf("migration not found")
+	ErrMigrationNotApplied   = fmt.Errorf("migration is not applied")
+	ErrMigrationIrreversible = fmt.Errorf("migration cannot be reverted")
+	ErrMigrationDrift        = fmt.Errorf("applied migrations differ from the code")
+)
+
+const (
+	// lockPollInterval is how often the migration lock is attempted while another session holds it
+	lockPollInterval = time.Second
+	// lockLogInterval is how often waiting for the migration lock is logged
+	lockLogInterval = 10 * time.Second
 )
 
 type Migrator struct {
@@ -23,16 +36,23 @@ type Migrator struct {
 	Dialect      Dialect
 	migrations   []Migration
 	migrationIds map[string]struct{}
-	Logger       log.Logger
-	isLocked     atomic.Bool
-	logMap       map[string]MigrationLog
-	tableName    string
+	Logger       *log.Logger
+	// FailOnDrift makes Start fail instead of only logging when applied migrations differ from the code
+	FailOnDrift    bool
+	isLocked       atomic.Bool
+	logMap         map[string]MigrationLog
+	tableName      string
+	logHasChecksum bool
+	// checkpointTable records the progress of the backfill migrations
+	checkpointTable string
+	scope           string
 }
 
 type MigrationLog struct {
 	Id          int64
 	MigrationID string `xorm:"migration_id"`
 	SQL         string `xorm:"sql"`
+	Checksum    string `xorm:"checksum"`
 	Success     bool
 	Error       string
 	Timestamp   time.Time
@@ -42,20 +62,24 @@ func NewMigrator(engine *xorm.Engine) *Migrator {
 	return NewScopedMigrator(engine, "")
 }
 
-// NewScopedMigrator should only be used for the transition to a new storage engine
+// NewScopedMigrator returns a migrator logging its migrations in <scope>_migration_log, it keeps the
+// migrations of a module apart from the others. An empty scope is the default migration_log.
 func NewScopedMigrator(engine *xorm.Engine, scope string) *Migrator {
 	mg := &Migrator{
 		DBEngine:     engine,
 		migrations:   make([]Migration, 0),
 		migrationIds: make(map[string]struct{}),
 		Dialect:      NewDialect(engine.DriverName()),
+		scope:        scope,
 	}
 	if scope == "" {
 		mg.tableName = "migration_log"
-		mg.Logger = log.Logger{}
+		mg.checkpointTable = "migration_checkpoint"
+		mg.Logger = log.New(log.Writer(), "migrator: ", log.LstdFlags)
 	} else {
 		mg.tableName = scope + "_migration_log"
-		mg.Logger = log.Logger{}
+		mg.checkpointTable = scope + "_migration_checkpoint"
+		mg.Logger = log.New(log.Writer(), "migrator["+scope+"]: ", log.LstdFlags)
 	}
 	return mg
 }
@@ -74,6 +98,30 @@ func (mg *Migrator) AddCreateMigration() {
 			{Name: "timestamp", Type: DB_DateTime},
 		},
 	}))
+
+	mg.AddMigration("add checksum column to "+mg.tableName, NewAddColumnMigration(Table{Name: mg.tableName}, &Column{
+		Name: "checksum", Type: DB_NVarchar, Length: 64, Nullable: true,
+	}))
+
+	checkpointTable := Table{
+		Name: mg.checkpointTable,
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "migration_id", Type: DB_NVarchar, Length: 255},
+			{Name: "last_key", Type: DB_BigInt},
+			{Name: "processed", Type: DB_BigInt},
+			{Name: "updated", Type: DB_DateTime},
+		},
+	}
+	mg.AddMigration("create "+mg.checkpointTable+" table", NewAddTableMigration(checkpointTable))
+	mg.AddMigration("add unique index "+mg.checkpointTable+".migration_id", NewAddIndexMigration(checkpointTable, &Index{
+		Cols: []string{"migration_id"}, Type: UniqueIndex,
+	}))
+}
+
+// Scope returns the scope of the migrator, empty for the default one.
+func (mg *Migrator) Scope() string {
+	return mg.scope
 }
 
 func (mg *Migrator) MigrationsCount() int {
@@ -113,7 +161,12 @@ func (mg *Migrator) GetMigrationLog() (map[string]MigrationLog, error) {
 		return logMap, nil
 	}
 
-	if err = mg.DBEngine.Table(mg.tableName).Find(&logItems); err != nil {
+	if err := mg.refreshLogHasChecksum(); err != nil {
+		return nil, err
+	}
+	sess := mg.DBEngine.NewSession()
+	defer sess.Close()
+	if err = mg.logTable(sess).Find(&logItems); err != nil {
 		return nil, err
 	}
 
@@ -128,6 +181,28 @@ func (mg *Migrator) GetMigrationLog() (map[string]MigrationLog, error) {
 	return logMap, nil
 }
 
+// logTable returns sess for the migration log table, without the checksum column
+// until the migration adding it has been applied.
+func (mg *Migrator) logTable(sess *xorm.Session) *xorm.Session {
+	sess = sess.Table(mg.tableName)
+	if !mg.logHasChecksum {
+		sess = sess.Omit("checksum")
+	}
+	return sess
+}
+
+func (mg *Migrator) refreshLogHasChecksum() error {
+	if mg.logHasChecksum {
+		return nil
+	}
+	exists, err := mg.DBEngine.Dialect().IsColumnExist(mg.DBEngine.DB(), context.Background(), mg.tableName, "checksum")
+	if err != nil {
+		return fmt.Errorf("%v: %w", "failed to check column existence", err)
+	}
+	mg.logHasChecksum = exists
+	return nil
+}
+
 func (mg *Migrator) RemoveMigrationLogs(migrationsIDs ...string) {
 	for _, id := range migrationsIDs {
 		delete(mg.logMap, id)
@@ -135,15 +210,37 @@ func (mg *Migrator) RemoveMigrationLogs(migrationsIDs ...string) {
 }
 
 func (mg *Migrator) Start(isDatabaseLockingEnabled bool, lockAttemptTimeout int) (err error) {
-	if !isDatabaseLockingEnabled {
-		return mg.run()
+	return mg.withLock(isDatabaseLockingEnabled, lockAttemptTimeout, mg.run)
+}
+
+// StartAll runs the migrations of the migrators one after the other, in the given order, holding the
+// migration lock once for all of them. It stops at the first migrator that fails.
+func StartAll(migrators []*Migrator, isDatabaseLockingEnabled bool, lockAttemptTimeout int) error {
+	if len(migrators) == 0 {
+		return nil
 	}
 
-	dbName, err := mg.Dialect.GetDBName(mg.DBEngine.DataSourceName())
-	if err != nil {
-		return err
+	// the lock key is derived from the database, it is the same for every scope
+	return migrators[0].withLock(isDatabaseLockingEnabled, lockAttemptTimeout, func() error {
+		for _, mg := range migrators {
+			if err := mg.run(); err != nil {
+				if mg.scope != "" {
+					return fmt.Errorf("%v: %w", fmt.Sprintf("migrations of scope %s failed", mg.scope), err)
+				}
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// withLock calls run holding the migration lock, or without lock when database locking is disabled.
+func (mg *Migrator) withLock(isDatabaseLockingEnabled bool, lockAttemptTimeout int, run func() error) error {
+	if !isDatabaseLockingEnabled {
+		return run()
 	}
-	key, err := database.GenerateAdvisoryLockId(dbName)
+
+	key, err := mg.lockKey()
 	if err != nil {
 		return err
 	}
@@ -155,21 +252,105 @@ func (mg *Migrator) Start(isDatabaseLockingEnabled bool, lockAttemptTimeout int)
 			Timeout: lockAttemptTimeout,
 		}
 
-		if err := casRestoreOnErr(&mg.isLocked, false, true, ErrMigratorIsLocked, mg.Dialect.Lock, lockCfg); err != nil {
+		if err := casRestoreOnErr(&mg.isLocked, false, true, ErrMigratorIsLocked, mg.lock, lockCfg); err != nil {
 			return err
 		}
 
 		defer func() {
 			unlockErr := casRestoreOnErr(&mg.isLocked, true, false, ErrMigratorIsUnlocked, mg.Dialect.Unlock, lockCfg)
 			if unlockErr != nil {
+				mg.Logger.Printf("failed to release the migration lock: %v", unlockErr)
 			}
 		}()
 
-		// migration will run inside a nested transaction
-		return mg.run()
+		// migration will run inside a nested transaction, the migrations applied by
+		// the instance that held the lock before are skipped as the log is read again
+		return run()
 	})
 }
 
+// lock acquires the migration lock, retrying until lockCfg.Timeout seconds have passed
+// while another session holds it. A timeout of zero makes a single attempt.
+func (mg *Migrator) lock(lockCfg LockCfg) error {
+	start := time.Now()
+	deadline := start.Add(time.Duration(lockCfg.Timeout) * time.Second)
+	var lastLog time.Time
+
+	for {
+		attemptStart := time.Now()
+		attempt := lockCfg
+		attempt.Timeout = 0
+		if time.Until(deadline) >= lockPollInterval {
+			// dialects waiting for the lock themselves wait at most one poll interval per attempt
+			attempt.Timeout = int(lockPollInterval / time.Second)
+		}
+
+		err := mg.Dialect.Lock(attempt)
+		if !errors.Is(err, ErrLockDB) {
+			if err == nil && !lastLog.IsZero() {
+				mg.Logger.Printf("acquired the migration lock after waiting %s", time.Since(start).Round(time.Second))
+			}
+			return err
+		}
+
+		holder := mg.lockHolder(lockCfg)
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%w: gave up after %ds, the lock is held by %s", ErrLockDB, lockCfg.Timeout, holder)
+		}
+		if time.Since(lastLog) >= lockLogInterval {
+			mg.Logger.Printf("waiting for the migration lock held by %s, giving up in %s", holder, time.Until(deadline).Round(time.Second))
+			lastLog = time.Now()
+		}
+
+		if elapsed := time.Since(attemptStart); elapsed < lockPollInterval {
+			time.Sleep(lockPollInterval - elapsed)
+		}
+	}
+}
+
+// lockHolder describes the holder of the migration lock. It uses a session of its own,
+// a failing query must not abort the transaction of the session waiting for the lock.
+func (mg *Migrator) lockHolder(lockCfg LockCfg) string {
+	sess := mg.DBEngine.NewSession()
+	defer sess.Close()
+
+	holder, err := mg.Dialect.LockHolder(LockCfg{Session: sess, Key: lockCfg.Key})
+	if err != nil || holder == "" {
+		return "another session"
+	}
+	return holder
+}
+
+// IsLocked reports whether the migration lock of the database is currently held by another session.
+// Dialects without database locking always report the lock as free.
+func (mg *Migrator) IsLocked() (bool, error) {
+	key, err := mg.lockKey()
+	if err != nil {
+		return false, err
+	}
+
+	sess := mg.DBEngine.NewSession()
+	defer sess.Close()
+
+	lockCfg := LockCfg{Session: sess, Key: key}
+	if err := mg.Dialect.Lock(lockCfg); err != nil {
+		if errors.Is(err, ErrLockDB) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return false, mg.Dialect.Unlock(lockCfg)
+}
+
+func (mg *Migrator) lockKey() (string, error) {
+	dbName, err := mg.Dialect.GetDBName(mg.DBEngine.DataSourceName())
+	if err != nil {
+		return "", err
+	}
+	return database.GenerateAdvisoryLockId(dbName)
+}
+
 func (mg *Migrator) run() (err error) {
 
 	_, err = mg.GetMigrationLog()
@@ -177,6 +358,10 @@ func (mg *Migrator) run() (err error) {
 		return err
 	}
 
+	if err := mg.checkDrift(); err != nil {
+		return err
+	}
+
 	migrationsPerformed := 0
 	migrationsSkipped := 0
 	for _, m := range mg.migrations {
@@ -192,38 +377,91 @@ func (mg *Migrator) run() (err error) {
 		record := MigrationLog{
 			MigrationID: m.Id(),
 			SQL:         sql,
+			Checksum:    Checksum(sql),
 			Timestamp:   time.Now(),
 		}
 
-		err := mg.InTransaction(func(sess *xorm.Session) error {
-			err := mg.exec(m, sess)
-			if err != nil {
-				record.Error = err.Error()
-				if !m.SkipMigrationLog() {
-					if _, err := sess.Table(mg.tableName).Insert(&record); err != nil {
-						return err
-					}
-				}
+		err := mg.inSession(m, func(sess *xorm.Session) error {
+			if err := mg.dropInvalidIndex(m, sess); err != nil {
 				return err
 			}
-			record.Success = true
-			if !m.SkipMigrationLog() {
-				_, err = sess.Table(mg.tableName).Insert(&record)
-			}
-			if err == nil {
-				migrationsPerformed++
+			if err := mg.exec(m, sess); err != nil {
+				return err
 			}
-			return err
+			record.Success = true
+			return mg.insertLog(m, sess, &record)
 		})
 		if err != nil {
+			// the failure is logged in a session of its own, the transaction of the migration is rolled back
+			record.Success = false
+			record.Error = err.Error()
+			if logErr := mg.insertLog(m, nil, &record); logErr != nil {
+				mg.Logger.Printf("failed to log the failure of migration %q: %v", m.Id(), logErr)
+			}
 			return fmt.Errorf("%v: %w", fmt.Sprintf("migration failed (id = %s)", m.Id()), err)
 		}
+		migrationsPerformed++
+
+		if err := mg.refreshLogHasChecksum(); err != nil {
+			return err
+		}
+	}
+
+	if migrationsPerformed > 0 {
+		mg.Logger.Printf("migrations completed, performed: %d, skipped: %d", migrationsPerformed, migrationsSkipped)
 	}
 
 	// Make sure migrations are synced
 	return mg.DBEngine.Sync2()
 }
 
+// inSession calls callback in a transaction, or in a session without transaction for the
+// migrations that cannot run in one.
+func (mg *Migrator) inSession(m Migration, callback dbTransactionFunc) error {
+	if nt, ok := m.(NonTransactionalMigration); ok && nt.NoTransaction(mg.Dialect) {
+		sess := mg.DBEngine.NewSession()
+		defer sess.Close()
+		return callback(sess)
+	}
+	return mg.InTransaction(callback)
+}
+
+// insertLog inserts the log record of the migration, in a new session if sess is nil.
+func (mg *Migrator) insertLog(m Migration, sess *xorm.Session, record *MigrationLog) error {
+	if m.SkipMigrationLog() {
+		return nil
+	}
+	if sess == nil {
+		sess = mg.DBEngine.NewSession()
+		defer sess.Close()
+	}
+	_, err := mg.logTable(sess).Insert(record)
+	return err
+}
+
+// dropInvalidIndex drops the invalid index a failed concurrent build of the index of m left behind,
+// the index would otherwise be considered as existing by the condition of the migration.
+func (mg *Migrator) dropInvalidIndex(m Migration, sess *xorm.Session) error {
+	am, ok := m.(*AddIndexMigration)
+	if !ok || !am.index.Concurrent {
+		return nil
+	}
+
+	name := am.index.XName(am.tableName)
+	sql, args := mg.Dialect.InvalidIndexCheckSQL(am.tableName, name)
+	if sql == "" {
+		return nil
+	}
+	results, err := sess.SQL(sql, args...).Query()
+	if err != nil || len(results) == 0 {
+		return err
+	}
+
+	mg.Logger.Printf("dropping index %s left invalid by a failed concurrent build", name)
+	_, err = sess.Exec(mg.Dialect.DropIndexSQL(am.tableName, am.index))
+	return err
+}
+
 func (mg *Migrator) exec(m Migration, sess *xorm.Session) error {
 
 	condition := m.GetCondition()
@@ -257,6 +495,82 @@ func (mg *Migrator) exec(m Migration, sess *xorm.Session) error {
 	return nil
 }
 
+// RollbackTo reverts, newest first, every applied migration registered after the migration with the given id,
+// which stays applied. Each migration is reverted in its own transaction, or without one for non-transactional
+// migrations, and its migration_log entries are deleted.
+// Nothing is reverted if one of the applied migrations in the range cannot be reverted.
+// Like Start, it holds the migration lock when database locking is enabled.
+func (mg *Migrator) RollbackTo(id string, isDatabaseLockingEnabled bool, lockAttemptTimeout int) error {
+	return mg.withLock(isDatabaseLockingEnabled, lockAttemptTimeout, func() error {
+		return mg.rollbackTo(id)
+	})
+}
+
+func (mg *Migrator) rollbackTo(id string) error {
+	if _, ok := mg.migrationIds[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrMigrationNotFound, id)
+	}
+
+	logMap, err := mg.GetMigrationLog()
+	if err != nil {
+		return err
+	}
+	if _, applied := logMap[id]; !applied {
+		return fmt.Errorf("%w: %s", ErrMigrationNotApplied, id)
+	}
+
+	toRevert := make([]Migration, 0)
+	for i := len(mg.migrations) - 1; i >= 0; i-- {
+		m := mg.migrations[i]
+		if m.Id() == id {
+			break
+		}
+		if _, applied := logMap[m.Id()]; !applied {
+			continue
+		}
+		if !mg.isReversible(m) {
+			return fmt.Errorf("%w: %s", ErrMigrationIrreversible, m.Id())
+		}
+		toRevert = append(toRevert, m)
+	}
+
+	for _, m := range toRevert {
+		m := m
+		err := mg.inSession(m, func(sess *xorm.Session) error {
+			if err := mg.execDown(m, sess); err != nil {
+				return err
+			}
+			_, err := sess.Table(mg.tableName).Where("migration_id = ?", m.Id()).Delete(&MigrationLog{})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%v: %w", fmt.Sprintf("rollback failed (id = %s)", m.Id()), err)
+		}
+		mg.RemoveMigrationLogs(m.Id())
+	}
+
+	return nil
+}
+
+func (mg *Migrator) isReversible(m Migration) bool {
+	rm, hasDownSQL := m.(ReversibleMigration)
+	if _, ok := m.(CodeMigration); ok {
+		_, ok := m.(ReversibleCodeMigration)
+		// an empty DownSQL also tells that a code migration cannot be reverted
+		return ok && (!hasDownSQL || rm.DownSQL(mg.Dialect) != "")
+	}
+	return hasDownSQL && rm.DownSQL(mg.Dialect) != ""
+}
+
+func (mg *Migrator) execDown(m Migration, sess *xorm.Session) error {
+	if codeMigration, ok := m.(ReversibleCodeMigration); ok {
+		return codeMigration.Down(sess, mg)
+	}
+
+	_, err := sess.Exec(m.(ReversibleMigration).DownSQL(mg.Dialect))
+	return err
+}
+
 type dbTransactionFunc func(sess *xorm.Session) error
 
 func (mg *Migrator) InTransaction(callback dbTransactionFunc) error {
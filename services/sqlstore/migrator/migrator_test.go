@@ -0,0 +1,43 @@
+package migrator
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"xorm.io/xorm"
+)
+
+// newTestEngine returns an engine on a SQLite file in a temporary directory of the test.
+func newTestEngine(t *testing.T) *xorm.Engine {
+	t.Helper()
+	engine, err := xorm.NewEngine(SQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = engine.Close()
+	})
+	return engine
+}
+
+// newTestMigrator returns a migrator with the migration log and the migrations added by register,
+// what it logs is written to the returned buffer.
+func newTestMigrator(engine *xorm.Engine, register func(mg *Migrator)) (*Migrator, *bytes.Buffer) {
+	mg := NewMigrator(engine)
+	logs := &bytes.Buffer{}
+	mg.Logger = log.New(logs, "", 0)
+	mg.AddCreateMigration()
+	register(mg)
+	return mg, logs
+}
+
+// testTable is a table with an integer primary key and a name.
+var testTable = Table{
+	Name: "test_item",
+	Columns: []*Column{
+		{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+		{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+	},
+}
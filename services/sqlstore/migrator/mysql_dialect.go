@@ -0,0 +1,403 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"xorm.io/xorm"
+)
+
+// MySQL server error numbers, see https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	mysqlErrDupEntry     = 1062
+	mysqlErrLockDeadlock = 1213
+)
+
+type MySQLDialect struct {
+	BaseDialect
+}
+
+func NewMysqlDialect() Dialect {
+	d := MySQLDialect{}
+	d.BaseDialect.dialect = &d
+	d.BaseDialect.driverName = MySQL
+	return &d
+}
+
+func (db *MySQLDialect) SupportEngine() bool {
+	return true
+}
+
+func (db *MySQLDialect) Quote(name string) string {
+	return "`" + name + "`"
+}
+
+func (db *MySQLDialect) AutoIncrStr() string {
+	return "AUTO_INCREMENT"
+}
+
+func (db *MySQLDialect) BooleanStr(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+func (db *MySQLDialect) BatchSize() int {
+	return 1000
+}
+
+func (db *MySQLDialect) SQLType(c *Column) string {
+	var res string
+	switch c.Type {
+	case DB_Bool:
+		res = DB_TinyInt
+		c.Length = 1
+	case DB_Serial:
+		c.IsAutoIncrement = true
+		c.IsPrimaryKey = true
+		c.Nullable = false
+		res = DB_Int
+	case DB_BigSerial:
+		c.IsAutoIncrement = true
+		c.IsPrimaryKey = true
+		c.Nullable = false
+		res = DB_BigInt
+	case DB_Bytea:
+		res = DB_Blob
+	case DB_TimeStampz:
+		res = DB_Char
+		c.Length = 64
+	case DB_NVarchar:
+		res = DB_Varchar
+	case DB_Uuid:
+		res = DB_Char
+		c.Length = 36
+	default:
+		res = c.Type
+	}
+
+	var hasLen1 = (c.Length > 0)
+	var hasLen2 = (c.Length2 > 0)
+
+	if res == DB_BigInt && !hasLen1 && !hasLen2 {
+		c.Length = 20
+		hasLen1 = true
+	}
+
+	if hasLen2 {
+		res += "(" + strconv.Itoa(c.Length) + "," + strconv.Itoa(c.Length2) + ")"
+	} else if hasLen1 {
+		res += "(" + strconv.Itoa(c.Length) + ")"
+	}
+
+	switch c.Type {
+	case DB_Char, DB_Varchar, DB_NVarchar, DB_TinyText, DB_Text, DB_MediumText, DB_LongText:
+		res += " CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci"
+	}
+
+	return res
+}
+
+func (db *MySQLDialect) UpdateTableSQL(tableName string, columns []*Column) string {
+	var statements = []string{}
+
+	statements = append(statements, "DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci")
+
+	for _, col := range columns {
+		statements = append(statements, "MODIFY "+col.StringNoPk(db))
+	}
+
+	return "ALTER TABLE " + db.Quote(tableName) + " " + strings.Join(statements, ", ") + ";"
+}
+
+func (db *MySQLDialect) CreateTableSQL(table *Table) string {
+	sql := db.BaseDialect.CreateTableSQL(table)
+	if table.Comment != "" {
+		sql = strings.TrimSuffix(sql, ";") + " COMMENT=" + db.quoteString(table.Comment) + ";"
+	}
+	return sql
+}
+
+func (db *MySQLDialect) ColString(col *Column) string {
+	return db.BaseDialect.ColString(col) + db.columnComment(col)
+}
+
+func (db *MySQLDialect) ColStringNoPk(col *Column) string {
+	return db.BaseDialect.ColStringNoPk(col) + db.columnComment(col)
+}
+
+func (db *MySQLDialect) columnComment(col *Column) string {
+	if col.Comment == "" {
+		return ""
+	}
+	return "COMMENT " + db.quoteString(col.Comment) + " "
+}
+
+// quoteString also escapes backslashes, which MySQL treats as escape characters in string literals
+func (db *MySQLDialect) quoteString(value string) string {
+	return quoteString(strings.ReplaceAll(value, `\`, `\\`))
+}
+
+func (db *MySQLDialect) DropForeignKeySQL(tableName string, fk *ForeignKey) string {
+	quote := db.Quote
+	return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", quote(tableName), quote(fk.XName(tableName)))
+}
+
+func (db *MySQLDialect) DropCheckSQL(tableName string, check *Check) string {
+	quote := db.Quote
+	return fmt.Sprintf("ALTER TABLE %s DROP CHECK %s", quote(tableName), quote(check.XName(tableName)))
+}
+
+// CreateIndexSQL builds concurrent indexes online, without locking writes. MySQL has no partial indexes,
+// so the Where clause of the index is left out. It panics on a unique partial index, which would
+// reject the rows the Where clause leaves out.
+func (db *MySQLDialect) CreateIndexSQL(tableName string, index *Index) string {
+	if index.Where != "" && index.Type == UniqueIndex {
+		panic(fmt.Errorf("failed to create index '%s': MySQL does not support unique partial indexes", index.XName(tableName)))
+	}
+	idx := *index
+	idx.Where = ""
+	sql := db.BaseDialect.CreateIndexSQL(tableName, &idx)
+	if index.Concurrent {
+		sql = strings.TrimSuffix(sql, ";") + " ALGORITHM=INPLACE LOCK=NONE;"
+	}
+	return sql
+}
+
+func (db *MySQLDialect) IndexCheckSQL(tableName, indexName string) (string, []interface{}) {
+	args := []interface{}{tableName, indexName}
+	sql := "SELECT 1 FROM " + db.Quote("INFORMATION_SCHEMA") + "." + db.Quote("STATISTICS") + " WHERE " + db.Quote("TABLE_SCHEMA") + " = DATABASE() AND " + db.Quote("TABLE_NAME") + "=? AND " + db.Quote("INDEX_NAME") + "=?"
+	return sql, args
+}
+
+func (db *MySQLDialect) ColumnCheckSQL(tableName, columnName string) (string, []interface{}) {
+	args := []interface{}{tableName, columnName}
+	sql := "SELECT 1 FROM " + db.Quote("INFORMATION_SCHEMA") + "." + db.Quote("COLUMNS") + " WHERE " + db.Quote("TABLE_SCHEMA") + " = DATABASE() AND " + db.Quote("TABLE_NAME") + "=? AND " + db.Quote("COLUMN_NAME") + "=?"
+	return sql, args
+}
+
+func (db *MySQLDialect) AlterColumnSQL(tableName string, col *Column) string {
+	return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;", db.Quote(tableName), db.ColStringNoPk(col))
+}
+
+// CopyColumnTriggerSQL creates BEFORE triggers, MySQL does not allow triggers to update their own table
+func (db *MySQLDialect) CopyColumnTriggerSQL(tableName, triggerName, from, to string) []string {
+	quote := db.Quote
+	set := fmt.Sprintf("SET NEW.%s = NEW.%s", quote(to), quote(from))
+	return []string{
+		db.triggerSQL(tableName, triggerName+"_insert", "BEFORE INSERT", set),
+		db.triggerSQL(tableName, triggerName+"_update", "BEFORE UPDATE", set),
+	}
+}
+
+func (db *MySQLDialect) CopyRowsTriggerSQL(tableName, triggerName, targetTable string, cols []string, pk string) []string {
+	quote := db.Quote
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quote(targetTable), db.QuoteColList(cols), newValues(db, cols))
+	del := fmt.Sprintf("DELETE FROM %s WHERE %s = OLD.%s", quote(targetTable), quote(pk), quote(pk))
+	return []string{
+		db.triggerSQL(tableName, triggerName+"_insert", "AFTER INSERT", insert),
+		db.triggerSQL(tableName, triggerName+"_update", "AFTER UPDATE", "BEGIN "+del+"; "+insert+"; END"),
+		db.triggerSQL(tableName, triggerName+"_delete", "AFTER DELETE", del),
+	}
+}
+
+func (db *MySQLDialect) triggerSQL(tableName, triggerName, timing, body string) string {
+	quote := db.Quote
+	return fmt.Sprintf("CREATE TRIGGER %s %s ON %s FOR EACH ROW %s", quote(triggerName), timing, quote(tableName), body)
+}
+
+func (db *MySQLDialect) RenameColumn(table Table, column *Column, newName string) string {
+	quote := db.dialect.Quote
+	return fmt.Sprintf(
+		"ALTER TABLE %s CHANGE %s %s %s",
+		quote(table.Name), quote(column.Name), quote(newName), db.SQLType(column),
+	)
+}
+
+func (db *MySQLDialect) CleanDB(engine *xorm.Engine) error {
+	tables, err := engine.DBMetas()
+	if err != nil {
+		return err
+	}
+	sess := engine.NewSession()
+	defer sess.Close()
+
+	if _, err := sess.Exec("set foreign_key_checks = 0"); err != nil {
+		return fmt.Errorf("failed to disable foreign key checks: %w", err)
+	}
+	for _, table := range tables {
+		if _, err := sess.Exec("drop table " + db.Quote(table.Name) + " ;"); err != nil {
+			return fmt.Errorf("failed to delete table %q: %w", table.Name, err)
+		}
+	}
+	if _, err := sess.Exec("set foreign_key_checks = 1"); err != nil {
+		return fmt.Errorf("failed to enable foreign key checks: %w", err)
+	}
+
+	return nil
+}
+
+// TruncateDBTables truncates all the tables.
+func (db *MySQLDialect) TruncateDBTables(engine *xorm.Engine) error {
+	tables, err := engine.DBMetas()
+	if err != nil {
+		return err
+	}
+	sess := engine.NewSession()
+	defer sess.Close()
+
+	for _, table := range tables {
+		switch table.Name {
+		case "":
+			continue
+		case "migration_log":
+			continue
+		default:
+			if _, err := sess.Exec(fmt.Sprintf("TRUNCATE TABLE %v;", db.Quote(table.Name))); err != nil {
+				return fmt.Errorf("failed to truncate table %q: %w", table.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (db *MySQLDialect) isThisError(err error, errcode uint16) bool {
+	var driverErr *mysql.MySQLError
+	if errors.As(err, &driverErr) {
+		if driverErr.Number == errcode {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (db *MySQLDialect) ErrorMessage(err error) string {
+	var driverErr *mysql.MySQLError
+	if errors.As(err, &driverErr) {
+		return driverErr.Message
+	}
+	return ""
+}
+
+func (db *MySQLDialect) IsUniqueConstraintViolation(err error) bool {
+	return db.isThisError(err, mysqlErrDupEntry)
+}
+
+func (db *MySQLDialect) IsDeadlock(err error) bool {
+	return db.isThisError(err, mysqlErrLockDeadlock)
+}
+
+// UpsertSQL returns the upsert sql statement for MySQL dialect
+func (db *MySQLDialect) UpsertSQL(tableName string, keyCols, updateCols []string) string {
+	str, _ := db.UpsertMultipleSQL(tableName, keyCols, updateCols, 1)
+	return str
+}
+
+// UpsertMultipleSQL returns the upsert sql statement for MySQL dialect,
+// the conflicting key is resolved by MySQL from the unique indices of the table
+func (db *MySQLDialect) UpsertMultipleSQL(tableName string, keyCols, updateCols []string, count int) (string, error) {
+	if count < 1 {
+		return "", fmt.Errorf("upsert statement must have count >= 1. Got %v", count)
+	}
+	columnsStr := strings.Builder{}
+	colPlaceHoldersStr := strings.Builder{}
+	setStr := strings.Builder{}
+
+	const separator = ", "
+	separatorVar := separator
+	for i, c := range updateCols {
+		if i == len(updateCols)-1 {
+			separatorVar = ""
+		}
+
+		columnsStr.WriteString(fmt.Sprintf("%s%s", db.Quote(c), separatorVar))
+		colPlaceHoldersStr.WriteString(fmt.Sprintf("?%s", separatorVar))
+		setStr.WriteString(fmt.Sprintf("%s=VALUES(%s)%s", db.Quote(c), db.Quote(c), separatorVar))
+	}
+
+	valuesStr := strings.Builder{}
+	separatorVar = separator
+	colPlaceHolders := colPlaceHoldersStr.String()
+	for i := 0; i < count; i++ {
+		if i == count-1 {
+			separatorVar = ""
+		}
+		valuesStr.WriteString(fmt.Sprintf("(%s)%s", colPlaceHolders, separatorVar))
+	}
+
+	s := fmt.Sprintf(`INSERT INTO %s (%s) VALUES %s ON DUPLICATE KEY UPDATE %s;`,
+		tableName,
+		columnsStr.String(),
+		valuesStr.String(),
+		setStr.String(),
+	)
+
+	return s, nil
+}
+
+func (db *MySQLDialect) Lock(cfg LockCfg) error {
+	// trying to obtain the lock with the specific name
+	// the lock is exclusive per session and is released explicitly by executing RELEASE_LOCK() or implicitly when the session terminates
+	// it returns 1 if the lock was obtained successfully,
+	// 0 if the attempt timed out (for example, because another client has previously locked the name),
+	// or NULL if an error occurred
+	query := "SELECT GET_LOCK(?, ?)"
+	var success sql.NullBool
+
+	_, err := cfg.Session.SQL(query, cfg.Key, cfg.Timeout).Get(&success)
+	if err != nil {
+		return err
+	}
+	if !success.Valid || !success.Bool {
+		return ErrLockDB
+	}
+
+	return nil
+}
+
+func (db *MySQLDialect) Unlock(cfg LockCfg) error {
+	// trying to release a previously-acquired exclusive lock with the specific name
+	// it returns 1 if the lock was released,
+	// 0 if the lock was not established by this thread (in which case the lock is not released),
+	// and NULL if the named lock did not exist
+	query := "SELECT RELEASE_LOCK(?)"
+	var success sql.NullBool
+
+	_, err := cfg.Session.SQL(query, cfg.Key).Get(&success)
+	if err != nil {
+		return err
+	}
+	if !success.Valid || !success.Bool {
+		return ErrReleaseLockDB
+	}
+	return nil
+}
+
+func (db *MySQLDialect) LockHolder(cfg LockCfg) (string, error) {
+	// IS_USED_LOCK returns the connection identifier of the client holding the lock, or NULL if it is free
+	query := `SELECT p.ID, p.USER, p.HOST FROM information_schema.PROCESSLIST p WHERE p.ID = IS_USED_LOCK(?)`
+	var id int64
+	var user, host string
+
+	has, err := cfg.Session.SQL(query, cfg.Key).Get(&id, &user, &host)
+	if err != nil || !has {
+		return "", err
+	}
+	return fmt.Sprintf("connection %d (user %s, host %s)", id, user, host), nil
+}
+
+func (db *MySQLDialect) GetDBName(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	return cfg.DBName, nil
+}
@@ -0,0 +1,22 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMySQLCreateIndexSQL(t *testing.T) {
+	dialect := NewDialect(MySQL)
+
+	partial := &Index{Cols: []string{"name"}, Where: "name <> ''"}
+	if sql := dialect.CreateIndexSQL("test_item", partial); strings.Contains(sql, "WHERE") {
+		t.Errorf("the partial index is created by %q, expected the Where clause to be left out", sql)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("CreateIndexSQL did not panic on a unique partial index")
+		}
+	}()
+	dialect.CreateIndexSQL("test_item", &Index{Cols: []string{"name"}, Type: UniqueIndex, Where: "name <> ''"})
+}
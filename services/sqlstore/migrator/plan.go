@@ -0,0 +1,131 @@
+package migrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PlanAction tells what Migrator.Start would do with a pending migration.
+type PlanAction string
+
+const (
+	// PlanRun migrations have no condition and always run.
+	PlanRun PlanAction = "run"
+	// PlanConditional migrations have a condition that is currently fulfilled, they run
+	// unless a previous migration of the plan changes the outcome of the condition.
+	PlanConditional PlanAction = "conditional"
+	// PlanSkip migrations have a condition that is currently not fulfilled, they are
+	// recorded in the migration log without running their SQL.
+	PlanSkip PlanAction = "skip"
+)
+
+type PlannedMigration struct {
+	// Scope is the scope of the migrator the migration is registered to, empty for the default one
+	Scope  string     `json:"scope,omitempty"`
+	ID     string     `json:"id"`
+	Action PlanAction `json:"action"`
+	SQL    string     `json:"sql"`
+	// IsCode is true for code migrations, SQL is then what gets logged, not what gets executed
+	IsCode bool   `json:"isCode,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// MigrationPlan lists the migrations that are not yet applied, in the order they would run.
+type MigrationPlan struct {
+	Dialect    string              `json:"dialect"`
+	Applied    int                 `json:"applied"`
+	Migrations []*PlannedMigration `json:"migrations"`
+}
+
+// Plan evaluates the pending migrations against the live database without changing it:
+// the conditions are queried and the SQL is rendered, nothing is executed or logged.
+func (mg *Migrator) Plan() (*MigrationPlan, error) {
+	logMap, err := mg.GetMigrationLog()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &MigrationPlan{
+		Dialect:    mg.Dialect.DriverName(),
+		Migrations: make([]*PlannedMigration, 0),
+	}
+
+	sess := mg.DBEngine.NewSession()
+	defer sess.Close()
+
+	for _, m := range mg.migrations {
+		if _, exists := logMap[m.Id()]; exists {
+			plan.Applied++
+			continue
+		}
+
+		planned := &PlannedMigration{
+			Scope:  mg.scope,
+			ID:     m.Id(),
+			Action: PlanRun,
+			SQL:    m.SQL(mg.Dialect),
+		}
+		if _, ok := m.(CodeMigration); ok {
+			planned.IsCode = true
+			planned.Note = "code migration, executes Go code"
+		}
+		if nt, ok := m.(NonTransactionalMigration); ok && nt.NoTransaction(mg.Dialect) {
+			planned.Note = "runs outside of a transaction"
+		}
+
+		if condition := m.GetCondition(); condition != nil {
+			if sql, args := condition.SQL(mg.Dialect); sql != "" {
+				planned.Action = PlanConditional
+				results, err := sess.SQL(sql, args...).Query()
+				if err != nil {
+					planned.Note = fmt.Sprintf("condition could not be evaluated: %v", err)
+				} else if !condition.IsFulfilled(results) {
+					planned.Action = PlanSkip
+				}
+			}
+		}
+
+		plan.Migrations = append(plan.Migrations, planned)
+	}
+
+	return plan, nil
+}
+
+// Merge appends the migrations of a plan of another scope, they run after those of p.
+func (p *MigrationPlan) Merge(other *MigrationPlan) {
+	p.Applied += other.Applied
+	p.Migrations = append(p.Migrations, other.Migrations...)
+}
+
+// WriteJSON writes the plan as indented JSON.
+func (p *MigrationPlan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// WriteText writes the plan in a form meant to be reviewed before running the migrations.
+func (p *MigrationPlan) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "-- dialect: %s, applied: %d, pending: %d\n", p.Dialect, p.Applied, len(p.Migrations))
+	for _, m := range p.Migrations {
+		fmt.Fprintf(&sb, "\n-- [%s] %s\n", m.Action, scopedID(m.Scope, m.ID))
+		if m.Note != "" {
+			fmt.Fprintf(&sb, "-- %s\n", m.Note)
+		}
+		sql := strings.TrimSpace(m.SQL)
+		if m.Action == PlanSkip {
+			sql = "-- " + strings.ReplaceAll(sql, "\n", "\n-- ")
+		}
+		sb.WriteString(sql)
+		if !strings.HasSuffix(sql, ";") {
+			sb.WriteString(";")
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
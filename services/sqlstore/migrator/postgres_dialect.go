@@ -102,9 +102,122 @@ func (db *PostgresDialect) IndexCheckSQL(tableName, indexName string) (string, [
 	return sql, args
 }
 
+// CreateTableSQL appends the COMMENT statements of the table and its columns to the CREATE TABLE statement
+func (db *PostgresDialect) CreateTableSQL(table *Table) string {
+	sql := db.BaseDialect.CreateTableSQL(table)
+	if table.Comment != "" {
+		sql += fmt.Sprintf("\nCOMMENT ON TABLE %s IS %s;", db.Quote(table.Name), quoteString(table.Comment))
+	}
+	for _, col := range table.Columns {
+		sql += db.columnComment(table.Name, col)
+	}
+	return sql
+}
+
+func (db *PostgresDialect) AddColumnSQL(tableName string, col *Column) string {
+	sql := db.BaseDialect.AddColumnSQL(tableName, col)
+	if comment := db.columnComment(tableName, col); comment != "" {
+		sql += ";" + comment
+	}
+	return sql
+}
+
+// AlterColumnSQL changes the type without a USING clause, so the old type has to be castable to the new one.
+// The comment is only changed when col has one.
+func (db *PostgresDialect) AlterColumnSQL(tableName string, col *Column) string {
+	name := db.Quote(col.Name)
+	actions := []string{"ALTER COLUMN " + name + " TYPE " + db.SQLType(col)}
+	if col.Nullable {
+		actions = append(actions, "ALTER COLUMN "+name+" DROP NOT NULL")
+	} else {
+		actions = append(actions, "ALTER COLUMN "+name+" SET NOT NULL")
+	}
+	if col.Default != "" {
+		actions = append(actions, "ALTER COLUMN "+name+" SET DEFAULT "+db.Default(col))
+	} else {
+		actions = append(actions, "ALTER COLUMN "+name+" DROP DEFAULT")
+	}
+
+	sql := fmt.Sprintf("ALTER TABLE %s %s;", db.Quote(tableName), strings.Join(actions, ", "))
+	return sql + db.columnComment(tableName, col)
+}
+
+// CopyColumnTriggerSQL creates a BEFORE trigger calling a function of the same name
+func (db *PostgresDialect) CopyColumnTriggerSQL(tableName, triggerName, from, to string) []string {
+	quote := db.Quote
+	body := fmt.Sprintf("NEW.%s := NEW.%s; RETURN NEW;", quote(to), quote(from))
+	return db.triggerSQL(tableName, triggerName, "BEFORE INSERT OR UPDATE", body)
+}
+
+// CopyRowsTriggerSQL creates an AFTER trigger calling a function of the same name, updates are applied
+// as a delete and an insert so that rows not copied yet are copied.
+func (db *PostgresDialect) CopyRowsTriggerSQL(tableName, triggerName, targetTable string, cols []string, pk string) []string {
+	quote := db.Quote
+	body := fmt.Sprintf("IF TG_OP IN ('UPDATE', 'DELETE') THEN DELETE FROM %s WHERE %s = OLD.%s; END IF; "+
+		"IF TG_OP IN ('INSERT', 'UPDATE') THEN INSERT INTO %s (%s) VALUES (%s); END IF; RETURN NULL;",
+		quote(targetTable), quote(pk), quote(pk), quote(targetTable), db.QuoteColList(cols), newValues(db, cols))
+	return db.triggerSQL(tableName, triggerName, "AFTER INSERT OR UPDATE OR DELETE", body)
+}
+
+func (db *PostgresDialect) triggerSQL(tableName, triggerName, timing, body string) []string {
+	quote := db.Quote
+	return []string{
+		fmt.Sprintf("CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$ BEGIN %s END; $$ LANGUAGE plpgsql", quote(triggerName), body),
+		fmt.Sprintf("CREATE TRIGGER %s %s ON %s FOR EACH ROW EXECUTE PROCEDURE %s()", quote(triggerName), timing, quote(tableName), quote(triggerName)),
+	}
+}
+
+func (db *PostgresDialect) DropTriggerSQL(tableName, triggerName string) []string {
+	quote := db.Quote
+	return []string{
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", quote(triggerName), quote(tableName)),
+		fmt.Sprintf("DROP FUNCTION IF EXISTS %s()", quote(triggerName)),
+	}
+}
+
+// SyncSequenceSQL is needed as inserting explicit values into a SERIAL column does not advance its sequence
+func (db *PostgresDialect) SyncSequenceSQL(tableName, pk string) string {
+	quote := db.Quote
+	return fmt.Sprintf("SELECT setval(pg_get_serial_sequence(%s, %s), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+		quoteString(quote(tableName)), quoteString(pk), quote(pk), quote(tableName))
+}
+
+func (db *PostgresDialect) ColumnCheckSQL(tableName, columnName string) (string, []interface{}) {
+	args := []interface{}{tableName, columnName}
+	sql := "SELECT 1 FROM information_schema.columns WHERE table_schema = current_schema() AND table_name=? AND column_name=?"
+	return sql, args
+}
+
+func (db *PostgresDialect) columnComment(tableName string, col *Column) string {
+	if col.Comment == "" {
+		return ""
+	}
+	return fmt.Sprintf("\nCOMMENT ON COLUMN %s.%s IS %s;", db.Quote(tableName), db.Quote(col.Name), quoteString(col.Comment))
+}
+
+// InvalidIndexCheckSQL finds the index left behind, marked invalid, when CREATE INDEX CONCURRENTLY fails
+func (db *PostgresDialect) InvalidIndexCheckSQL(tableName, indexName string) (string, []interface{}) {
+	args := []interface{}{tableName, indexName}
+	sql := "SELECT 1 FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid JOIN pg_class t ON t.oid = i.indrelid" +
+		" WHERE t.relname = ? AND c.relname = ? AND NOT i.indisvalid"
+	return sql, args
+}
+
+func (db *PostgresDialect) CreateIndexSQL(tableName string, index *Index) string {
+	sql := db.BaseDialect.CreateIndexSQL(tableName, index)
+	if index.Concurrent {
+		sql = strings.Replace(sql, " INDEX ", " INDEX CONCURRENTLY ", 1)
+	}
+	return sql
+}
+
 func (db *PostgresDialect) DropIndexSQL(tableName string, index *Index) string {
 	quote := db.Quote
 	idxName := index.XName(tableName)
+	if index.Concurrent {
+		// CASCADE is not supported together with CONCURRENTLY
+		return fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %v", quote(idxName))
+	}
 	return fmt.Sprintf("DROP INDEX %v CASCADE", quote(idxName))
 }
 
@@ -201,6 +314,10 @@ func (db *PostgresDialect) IsDeadlock(err error) bool {
 	return db.isThisError(err, "40P01")
 }
 
+func (db *PostgresDialect) IsRetryableError(err error) bool {
+	return db.IsDeadlock(err) || db.isThisError(err, "40001")
+}
+
 func (db *PostgresDialect) PostInsertId(table string, sess *xorm.Session) error {
 	if table != "org" {
 		return nil
@@ -302,6 +419,21 @@ func (db *PostgresDialect) Lock(cfg LockCfg) error {
 	return nil
 }
 
+func (db *PostgresDialect) LockHolder(cfg LockCfg) (string, error) {
+	// an advisory lock on a bigint key is reported with the high 32 bits in classid and the low 32 bits in objid
+	query := `SELECT a.pid, a.usename, COALESCE(a.application_name, ''), COALESCE(host(a.client_addr), 'local')
+		FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.granted AND l.objid::bigint = ?::bigint & 4294967295 AND l.classid::bigint = ?::bigint >> 32`
+	var pid int64
+	var user, appName, client string
+
+	has, err := cfg.Session.SQL(query, cfg.Key, cfg.Key).Get(&pid, &user, &appName, &client)
+	if err != nil || !has {
+		return "", err
+	}
+	return fmt.Sprintf("pid %d (user %s, application %q, client %s)", pid, user, appName, client), nil
+}
+
 // OrderBy normalizes ordering so that nulls end up last in sorting, which they do by default in both sqlite and mysql but not postgres
 // order should be a string like `dashboard.id ASC`
 func (db *PostgresDialect) OrderBy(order string) string {
@@ -0,0 +1,251 @@
+package migrator
+
+import (
+	"fmt"
+	"strings"
+
+	"xorm.io/xorm"
+)
+
+// ColumnRename renames a column without breaking the instances still running the old code, as an alternative
+// to NewRenameColumnMigration. Expand adds the new column and copies the data in batches, with DualWrite triggers
+// keep copying what is written to the old column meanwhile, so the new code reads the new column but has to write both.
+// Contract drops the old column, it has to be registered in a later release, once no instance uses the old column.
+type ColumnRename struct {
+	Table   Table
+	Column  *Column
+	NewName string
+	// Indices are the indices containing the column, they are created for the new column and dropped with the old one
+	Indices   []*Index
+	DualWrite bool
+}
+
+// Expand registers the migrations adding and filling the new column. The new column is nullable
+// until Contract, the rows inserted by the old code do not set it.
+func (r *ColumnRename) Expand(mg *Migrator) {
+	col := r.newColumn()
+	col.Nullable = true
+	mg.AddMigration(r.id("add column"), NewAddColumnMigration(r.Table, col))
+
+	if r.DualWrite {
+		mg.AddMigration(r.id("add dual-write triggers"), newCreateTriggerMigration(r.Table.Name, r.triggerName(), func(d Dialect) []string {
+			return d.CopyColumnTriggerSQL(r.Table.Name, r.triggerName(), r.Column.Name, r.NewName)
+		}))
+	}
+
+	mg.AddMigration(r.id("copy data"), NewBackfillSQLMigration(r.Table, func(d Dialect, where string) string {
+		quote := d.Quote
+		return fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s", quote(r.Table.Name), quote(r.NewName), quote(r.Column.Name), where)
+	}))
+
+	for _, index := range r.Indices {
+		idx := r.newIndex(index)
+		mg.AddMigration(r.id("add index "+idx.XName(r.Table.Name)), NewAddIndexMigration(r.Table, idx))
+	}
+}
+
+// Contract registers the migrations dropping the triggers and the old column, and making the new column
+// NOT NULL if the old one was.
+func (r *ColumnRename) Contract(mg *Migrator) {
+	if r.DualWrite {
+		mg.AddMigration(r.id("drop dual-write triggers"), newDropTriggerMigration(r.Table.Name, r.triggerName()))
+	}
+	for _, idx := range r.Indices {
+		mg.AddMigration(r.id("drop index "+idx.XName(r.Table.Name)), NewDropIndexMigration(r.Table, idx))
+	}
+	mg.AddMigration(r.id("drop column"), NewDropColumnMigration(r.Table, r.Column))
+
+	if !r.Column.Nullable {
+		nullable := r.newColumn()
+		nullable.Nullable = true
+		mg.AddMigration(r.id("set not null"), NewAlterColumnMigration(r.Table, r.newColumn()).From(nullable))
+	}
+}
+
+func (r *ColumnRename) newColumn() *Column {
+	col := *r.Column
+	col.Name = r.NewName
+	return &col
+}
+
+// newIndex returns the index with the new column in place of the old one, in the columns and the name.
+func (r *ColumnRename) newIndex(index *Index) *Index {
+	idx := *index
+	idx.Cols = make([]string, 0, len(index.Cols))
+	for _, col := range index.Cols {
+		if col == r.Column.Name {
+			col = r.NewName
+		}
+		idx.Cols = append(idx.Cols, col)
+	}
+	idx.Name = strings.ReplaceAll(index.Name, r.Column.Name, r.NewName)
+	return &idx
+}
+
+func (r *ColumnRename) id(step string) string {
+	return fmt.Sprintf("rename column %s.%s to %s: %s", r.Table.Name, r.Column.Name, r.NewName, step)
+}
+
+func (r *ColumnRename) triggerName() string {
+	return fmt.Sprintf("TRG_%s_%s_to_%s", r.Table.Name, r.Column.Name, r.NewName)
+}
+
+// TableRename renames a table without breaking the instances still running the old code, as an alternative
+// to NewRenameTableMigration. Expand creates the new table with the columns and indices of Table and copies the rows
+// in batches, with DualWrite triggers keep applying the writes to the old table meanwhile, so the new code reads the
+// new table but has to write the old one. Contract drops the old table, it has to be registered in a later release,
+// once no instance uses the old table.
+type TableRename struct {
+	Table     Table
+	NewName   string
+	DualWrite bool
+}
+
+// Expand registers the migrations creating and filling the new table. The triggers are created
+// before the rows are copied, so that no write is missed.
+func (r *TableRename) Expand(mg *Migrator) {
+	table := r.newTable()
+	mg.AddMigration(r.id("create table"), NewAddTableMigration(table))
+	for _, index := range r.Table.Indices {
+		idx := *index
+		if strings.HasPrefix(idx.Name, "IDX_") || strings.HasPrefix(idx.Name, "UQE_") {
+			// full names contain the old table name
+			idx.Name = ""
+		}
+		mg.AddMigration(r.id("add index "+idx.XName(table.Name)), NewAddIndexMigration(table, &idx))
+	}
+
+	cols := make([]string, 0, len(r.Table.Columns))
+	for _, col := range r.Table.Columns {
+		cols = append(cols, col.Name)
+	}
+	pk := primaryKey(r.Table)
+
+	if r.DualWrite {
+		mg.AddMigration(r.id("add dual-write triggers"), newCreateTriggerMigration(r.Table.Name, r.triggerName(), func(d Dialect) []string {
+			return d.CopyRowsTriggerSQL(r.Table.Name, r.triggerName(), r.NewName, cols, pk)
+		}))
+	}
+
+	backfill := NewBackfillSQLMigration(r.Table, func(d Dialect, where string) string {
+		quote := d.Quote
+		// the triggers may have copied a row already
+		return d.CopyTableData(r.Table.Name, r.NewName, cols, cols) + fmt.Sprintf(" WHERE %s AND NOT EXISTS (SELECT 1 FROM %s WHERE %s.%s = %s.%s)",
+			where, quote(r.NewName), quote(r.NewName), quote(pk), quote(r.Table.Name), quote(pk))
+	})
+	// the rows are copied with their pk
+	backfill.syncTable = r.NewName
+	mg.AddMigration(r.id("copy data"), backfill)
+}
+
+// Contract registers the migrations dropping the triggers and the old table.
+func (r *TableRename) Contract(mg *Migrator) {
+	if r.DualWrite {
+		pk := primaryKey(r.Table)
+		mg.AddMigration(r.id("drop dual-write triggers"), &statementsMigration{
+			up: func(d Dialect) []string {
+				statements := d.DropTriggerSQL(r.Table.Name, r.triggerName())
+				// the triggers inserted rows with their pk
+				if sync := d.SyncSequenceSQL(r.NewName, pk); sync != "" {
+					statements = append(statements, sync)
+				}
+				return statements
+			},
+		})
+	}
+	mg.AddMigration(r.id("drop table"), NewDropTableMigration(r.Table.Name))
+}
+
+func (r *TableRename) newTable() Table {
+	table := r.Table
+	table.Name = r.NewName
+	table.PrimaryKeys = nil
+	table.Indices = nil
+	return table
+}
+
+func (r *TableRename) id(step string) string {
+	return fmt.Sprintf("rename table %s to %s: %s", r.Table.Name, r.NewName, step)
+}
+
+func (r *TableRename) triggerName() string {
+	return fmt.Sprintf("TRG_%s_to_%s", r.Table.Name, r.NewName)
+}
+
+// primaryKey returns the name of the primary key column, the batches of the copy are ranges of it.
+func primaryKey(table Table) string {
+	for _, col := range table.Columns {
+		if col.IsPrimaryKey {
+			return col.Name
+		}
+	}
+	if len(table.PrimaryKeys) > 0 {
+		return table.PrimaryKeys[0]
+	}
+	return ""
+}
+
+// statementsMigration executes statements one by one, e.g. those creating the triggers of an expand phase.
+// down is nil when it cannot be reverted.
+type statementsMigration struct {
+	MigrationBase
+	up   func(d Dialect) []string
+	down func(d Dialect) []string
+}
+
+func newCreateTriggerMigration(tableName, triggerName string, create func(d Dialect) []string) *statementsMigration {
+	return &statementsMigration{
+		up: create,
+		down: func(d Dialect) []string {
+			return d.DropTriggerSQL(tableName, triggerName)
+		},
+	}
+}
+
+func newDropTriggerMigration(tableName, triggerName string) *statementsMigration {
+	return &statementsMigration{
+		up: func(d Dialect) []string {
+			return d.DropTriggerSQL(tableName, triggerName)
+		},
+	}
+}
+
+func (m *statementsMigration) SQL(d Dialect) string {
+	return joinStatements(d, m.up(d))
+}
+
+// DownSQL is empty when the migration cannot be reverted.
+func (m *statementsMigration) DownSQL(d Dialect) string {
+	if m.down == nil {
+		return ""
+	}
+	return joinStatements(d, m.down(d))
+}
+
+func (m *statementsMigration) Exec(sess *xorm.Session, mg *Migrator) error {
+	return execStatements(sess, m.up(mg.Dialect))
+}
+
+func (m *statementsMigration) Down(sess *xorm.Session, mg *Migrator) error {
+	if m.down == nil {
+		return fmt.Errorf("migration %s cannot be reverted", m.Id())
+	}
+	return execStatements(sess, m.down(mg.Dialect))
+}
+
+func joinStatements(d Dialect, statements []string) string {
+	if len(statements) == 0 {
+		return d.NoOpSQL()
+	}
+	return strings.Join(statements, ";\n")
+}
+
+// execStatements executes the statements one by one, MySQL does not allow several in one
+func execStatements(sess *xorm.Session, statements []string) error {
+	for _, statement := range statements {
+		if _, err := sess.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
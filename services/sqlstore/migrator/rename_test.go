@@ -0,0 +1,130 @@
+package migrator
+
+import "testing"
+
+func TestColumnRename(t *testing.T) {
+	table := Table{
+		Name: "item",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+		},
+		Indices: []*Index{{Cols: []string{"name"}}},
+	}
+	rename := &ColumnRename{Table: table, Column: table.Columns[1], NewName: "title", Indices: table.Indices, DualWrite: true}
+	register := func(mg *Migrator) {
+		mg.AddMigration("create item table", NewAddTableMigration(table))
+		mg.AddMigration("add index item.name", NewAddIndexMigration(table, table.Indices[0]))
+		mg.AddMigration("insert rows", NewRawSQLMigration("INSERT INTO item (id, name) VALUES (1, 'a'), (2, 'b')"))
+		rename.Expand(mg)
+	}
+
+	engine := newTestEngine(t)
+	count := func(query string) int {
+		t.Helper()
+		var n int
+		if _, err := engine.SQL(query).Get(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	exec := func(query string) {
+		t.Helper()
+		if _, err := engine.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mg, _ := newTestMigrator(engine, register)
+	if err := mg.Start(false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := count("SELECT COUNT(*) FROM item WHERE title = name"); n != 2 {
+		t.Errorf("Expand copied %d rows to title, expected 2", n)
+	}
+
+	// the old code writes name only, the triggers copy it to title
+	exec("INSERT INTO item (id, name) VALUES (3, 'c')")
+	exec("UPDATE item SET name = 'a2' WHERE id = 1")
+	if n := count("SELECT COUNT(*) FROM item WHERE (id = 1 AND title = 'a2') OR (id = 3 AND title = 'c')"); n != 2 {
+		t.Errorf("the dual-write triggers did not copy the writes to name")
+	}
+
+	mg, _ = newTestMigrator(engine, func(mg *Migrator) {
+		register(mg)
+		rename.Contract(mg)
+	})
+	if err := mg.Start(false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := count("SELECT COUNT(*) FROM pragma_table_info('item') WHERE name = 'name'"); n != 0 {
+		t.Errorf("Contract did not drop name")
+	}
+	if n := count("SELECT COUNT(*) FROM pragma_table_info('item') WHERE name = 'title' AND \"notnull\" = 1"); n != 1 {
+		t.Errorf("Contract did not make title NOT NULL")
+	}
+	if n := count("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger'"); n != 0 {
+		t.Errorf("Contract left %d triggers", n)
+	}
+	if n := count("SELECT COUNT(*) FROM item WHERE (id = 1 AND title = 'a2') OR (id = 2 AND title = 'b') OR (id = 3 AND title = 'c')"); n != 3 {
+		t.Errorf("Contract did not keep the rows")
+	}
+}
+
+func TestTableRename(t *testing.T) {
+	rename := &TableRename{Table: testTable, NewName: "item", DualWrite: true}
+	register := func(mg *Migrator) {
+		mg.AddMigration("create test_item table", NewAddTableMigration(testTable))
+		mg.AddMigration("insert rows", NewRawSQLMigration("INSERT INTO test_item (id, name) VALUES (1, 'a'), (2, 'b')"))
+		rename.Expand(mg)
+	}
+
+	engine := newTestEngine(t)
+	count := func(query string) int {
+		t.Helper()
+		var n int
+		if _, err := engine.SQL(query).Get(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	exec := func(query string) {
+		t.Helper()
+		if _, err := engine.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mg, _ := newTestMigrator(engine, register)
+	if err := mg.Start(false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := count("SELECT COUNT(*) FROM item WHERE (id = 1 AND name = 'a') OR (id = 2 AND name = 'b')"); n != 2 {
+		t.Errorf("Expand copied %d rows to item, expected 2", n)
+	}
+
+	// the old code writes test_item only, the triggers apply the writes to item
+	exec("INSERT INTO test_item (id, name) VALUES (3, 'c')")
+	exec("UPDATE test_item SET name = 'a2' WHERE id = 1")
+	exec("DELETE FROM test_item WHERE id = 2")
+	if n := count("SELECT COUNT(*) FROM item"); n != 2 {
+		t.Errorf("item has %d rows after the writes to test_item, expected 2", n)
+	}
+	if n := count("SELECT COUNT(*) FROM item WHERE (id = 1 AND name = 'a2') OR (id = 3 AND name = 'c')"); n != 2 {
+		t.Errorf("the dual-write triggers did not copy the writes to test_item")
+	}
+
+	mg, _ = newTestMigrator(engine, func(mg *Migrator) {
+		register(mg)
+		rename.Contract(mg)
+	})
+	if err := mg.Start(false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := count("SELECT COUNT(*) FROM sqlite_master WHERE name = 'test_item' OR type = 'trigger'"); n != 0 {
+		t.Errorf("Contract did not drop test_item and its triggers")
+	}
+	if n := count("SELECT COUNT(*) FROM item"); n != 2 {
+		t.Errorf("Contract did not keep the rows of item")
+	}
+}
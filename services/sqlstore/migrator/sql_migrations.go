@@ -0,0 +1,104 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// sqlFileMigration is a migration defined by the files of one version, keyed by direction and then by dialect.
+type sqlFileMigration struct {
+	version uint
+	id      string
+	files   map[source.Direction]map[string]string
+}
+
+// AddSQLMigrations registers the migrations defined by golang-migrate style files in dir of fsys, e.g. os.DirFS
+// or an embed.FS, in the order of their versions. A migration is the file NNNN_name.up.sql, optionally with
+// NNNN_name.down.sql reverting it and with variants for a dialect, e.g. NNNN_name.up.postgres.sql.
+// The id of the migration is the file name without direction and extension, e.g. 0001_create_team.
+// A file is executed at once, several statements need multiStatements=true in the MySQL connection string.
+func (mg *Migrator) AddSQLMigrations(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("%v: %w", "failed to read the migration files", err)
+	}
+
+	byVersion := make(map[uint]*sqlFileMigration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		file, err := source.Parse(entry.Name())
+		if errors.Is(err, source.ErrParse) {
+			// e.g. a README
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("invalid migration file %s: %w", entry.Name(), err)
+		}
+
+		dialect, err := sqlFileDialect(entry.Name())
+		if err != nil {
+			return err
+		}
+
+		id := entry.Name()[:strings.Index(entry.Name(), "_")+1+len(file.Identifier)]
+		m, ok := byVersion[file.Version]
+		if !ok {
+			m = &sqlFileMigration{version: file.Version, id: id, files: make(map[source.Direction]map[string]string)}
+			byVersion[file.Version] = m
+		}
+		if m.id != id {
+			return fmt.Errorf("migration files %s and %s have the same version", m.id, id)
+		}
+		if m.files[file.Direction] == nil {
+			m.files[file.Direction] = make(map[string]string)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("%v: %w", fmt.Sprintf("failed to read migration file %s", entry.Name()), err)
+		}
+		m.files[file.Direction][dialect] = string(content)
+	}
+
+	migrations := make([]*sqlFileMigration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if len(m.files[source.Up]) == 0 {
+			return fmt.Errorf("migration %s has no up file", m.id)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+	for _, m := range migrations {
+		raw := NewRawSQLMigration("")
+		for dialect, sql := range m.files[source.Up] {
+			raw.Set(dialect, sql)
+		}
+		for dialect, sql := range m.files[source.Down] {
+			raw.SetDown(dialect, sql)
+		}
+		mg.AddMigration(m.id, raw)
+	}
+	return nil
+}
+
+// sqlFileDialect returns the dialect a migration file is specific to, or "default" for NNNN_name.up.sql.
+func sqlFileDialect(name string) (string, error) {
+	for _, dialect := range []string{Postgres, MySQL, SQLite} {
+		if strings.HasSuffix(name, "."+dialect+".sql") {
+			return dialect, nil
+		}
+	}
+	if strings.HasSuffix(name, string(source.Up)+".sql") || strings.HasSuffix(name, string(source.Down)+".sql") {
+		return "default", nil
+	}
+	return "", fmt.Errorf("invalid migration file %s, expected the extension .sql, .postgres.sql, .mysql.sql or .sqlite3.sql", name)
+}
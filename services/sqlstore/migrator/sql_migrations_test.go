@@ -0,0 +1,125 @@
+package migrator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAddSQLMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_add_team_email.up.sql":           {Data: []byte("ALTER TABLE team ADD COLUMN email TEXT")},
+		"migrations/0002_add_team_email.down.sql":         {Data: []byte("ALTER TABLE team DROP COLUMN email")},
+		"migrations/0001_create_team.up.sql":              {Data: []byte("CREATE TABLE team (id INTEGER PRIMARY KEY, name TEXT)")},
+		"migrations/0001_create_team.up.sqlite3.sql":      {Data: []byte("CREATE TABLE team (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)")},
+		"migrations/0010_create_team_member.up.sql":       {Data: []byte("CREATE TABLE team_member (team_id INTEGER, user_id INTEGER)")},
+		"migrations/0010_create_team_member.up.mysql.sql": {Data: []byte("CREATE TABLE team_member (team_id BIGINT, user_id BIGINT)")},
+		"migrations/0010_create_team_member.down.sql":     {Data: []byte("DROP TABLE team_member")},
+		"migrations/README.md":                            {Data: []byte("# Migrations")},
+	}
+
+	engine := newTestEngine(t)
+	var addErr error
+	mg, _ := newTestMigrator(engine, func(mg *Migrator) {
+		addErr = mg.AddSQLMigrations(fsys, "migrations")
+	})
+	if addErr != nil {
+		t.Fatal(addErr)
+	}
+
+	ids := make([]string, 0)
+	for _, m := range mg.migrations {
+		if strings.HasPrefix(m.Id(), "00") {
+			ids = append(ids, m.Id())
+		}
+	}
+	if got, want := strings.Join(ids, ", "), "0001_create_team, 0002_add_team_email, 0010_create_team_member"; got != want {
+		t.Errorf("the migrations are %s, expected %s", got, want)
+	}
+
+	if err := mg.Start(false, 0); err != nil {
+		t.Fatal(err)
+	}
+	var createSQL string
+	if _, err := engine.SQL("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'team'").Get(&createSQL); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(createSQL, "AUTOINCREMENT") {
+		t.Errorf("team is created by %q, expected the sqlite3 variant", createSQL)
+	}
+
+	// the rollback waits for the migration lock like Start
+	mg.isLocked.Store(true)
+	if err := mg.RollbackTo("0001_create_team", true, 0); !errors.Is(err, ErrMigratorIsLocked) {
+		t.Fatalf("RollbackTo returned %v while the migrator is locked, expected ErrMigratorIsLocked", err)
+	}
+	mg.isLocked.Store(false)
+
+	if err := mg.RollbackTo("0001_create_team", true, 0); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if _, err := engine.SQL("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'team_member'").Get(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("team_member is not dropped by the down file")
+	}
+	if _, err := engine.SQL("SELECT COUNT(*) FROM pragma_table_info('team') WHERE name = 'email'").Get(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("team.email is not dropped by the down file")
+	}
+}
+
+func TestAddSQLMigrationsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		err   string
+	}{
+		{
+			name:  "same version",
+			files: []string{"0001_create_team.up.sql", "0001_create_user.up.sql"},
+			err:   "have the same version",
+		},
+		{
+			name:  "no up file",
+			files: []string{"0001_create_team.up.sql", "0002_add_team_email.down.sql"},
+			err:   "migration 0002_add_team_email has no up file",
+		},
+		{
+			name:  "extension",
+			files: []string{"0001_create_team.up.txt"},
+			err:   "invalid migration file 0001_create_team.up.txt",
+		},
+		{
+			name:  "dialect",
+			files: []string{"0001_create_team.up.oracle.sql"},
+			err:   "invalid migration file 0001_create_team.up.oracle.sql",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, name := range tt.files {
+				fsys["migrations/"+name] = &fstest.MapFile{Data: []byte("SELECT 1")}
+			}
+			mg := NewMigrator(newTestEngine(t))
+			err := mg.AddSQLMigrations(fsys, "migrations")
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("AddSQLMigrations returned %v, expected an error containing %q", err, tt.err)
+			}
+			if len(mg.migrations) != 0 {
+				t.Errorf("%d migrations are registered despite the error", len(mg.migrations))
+			}
+		})
+	}
+
+	mg := NewMigrator(newTestEngine(t))
+	if err := mg.AddSQLMigrations(fstest.MapFS{}, "migrations"); err == nil {
+		t.Errorf("AddSQLMigrations accepts a directory that does not exist")
+	}
+}
@@ -0,0 +1,288 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+	"xorm.io/xorm"
+)
+
+type SQLite3Dialect struct {
+	BaseDialect
+}
+
+func NewSQLite3Dialect() Dialect {
+	d := SQLite3Dialect{}
+	d.BaseDialect.dialect = &d
+	d.BaseDialect.driverName = SQLite
+	return &d
+}
+
+func (db *SQLite3Dialect) SupportEngine() bool {
+	return false
+}
+
+func (db *SQLite3Dialect) Quote(name string) string {
+	return "`" + name + "`"
+}
+
+func (db *SQLite3Dialect) AutoIncrStr() string {
+	return "AUTOINCREMENT"
+}
+
+func (db *SQLite3Dialect) BooleanStr(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+func (db *SQLite3Dialect) DateTimeFunc(value string) string {
+	return "datetime(" + value + ")"
+}
+
+func (db *SQLite3Dialect) BatchSize() int {
+	// SQLite allows 999 parameters per statement before 3.32 and 32766 since, so a batch
+	// fits a statement binding a parameter per row on any version.
+	return 999
+}
+
+func (db *SQLite3Dialect) SQLType(c *Column) string {
+	switch c.Type {
+	case DB_Date, DB_DateTime, DB_TimeStamp, DB_Time:
+		return DB_DateTime
+	case DB_TimeStampz:
+		return DB_Text
+	case DB_Char, DB_Varchar, DB_NVarchar, DB_TinyText, DB_Text, DB_MediumText, DB_LongText, DB_Uuid:
+		return DB_Text
+	case DB_Bit, DB_TinyInt, DB_SmallInt, DB_MediumInt, DB_Int, DB_Integer, DB_BigInt, DB_Bool:
+		return DB_Integer
+	case DB_Float, DB_Double, DB_Real:
+		return DB_Real
+	case DB_Decimal, DB_Numeric:
+		return DB_Numeric
+	case DB_TinyBlob, DB_Blob, DB_MediumBlob, DB_LongBlob, DB_Bytea, DB_Binary, DB_VarBinary:
+		return DB_Blob
+	case DB_Serial, DB_BigSerial:
+		c.IsPrimaryKey = true
+		c.IsAutoIncrement = true
+		c.Nullable = false
+		return DB_Integer
+	default:
+		return c.Type
+	}
+}
+
+func (db *SQLite3Dialect) IndexCheckSQL(tableName, indexName string) (string, []interface{}) {
+	args := []interface{}{tableName, indexName}
+	sql := "SELECT 1 FROM pragma_index_list(?) WHERE " + db.Quote("name") + "=?"
+	return sql, args
+}
+
+func (db *SQLite3Dialect) ColumnCheckSQL(tableName, columnName string) (string, []interface{}) {
+	args := []interface{}{tableName, columnName}
+	sql := "SELECT 1 FROM pragma_table_info(?) WHERE " + db.Quote("name") + "=?"
+	return sql, args
+}
+
+// AddConstraintSQL returns an empty string, sqlite cannot add constraints to an existing table
+func (db *SQLite3Dialect) AddConstraintSQL(tableName string, definition string) string {
+	return ""
+}
+
+// DropForeignKeySQL returns an empty string, sqlite cannot drop constraints of an existing table
+func (db *SQLite3Dialect) DropForeignKeySQL(tableName string, fk *ForeignKey) string {
+	return ""
+}
+
+// DropCheckSQL returns an empty string, sqlite cannot drop constraints of an existing table
+func (db *SQLite3Dialect) DropCheckSQL(tableName string, check *Check) string {
+	return ""
+}
+
+// CopyColumnTriggerSQL creates AFTER triggers updating the written row, sqlite triggers cannot change NEW
+func (db *SQLite3Dialect) CopyColumnTriggerSQL(tableName, triggerName, from, to string) []string {
+	quote := db.Quote
+	update := fmt.Sprintf("UPDATE %s SET %s = NEW.%s WHERE rowid = NEW.rowid", quote(tableName), quote(to), quote(from))
+	return []string{
+		db.triggerSQL(tableName, triggerName+"_insert", "AFTER INSERT", update),
+		db.triggerSQL(tableName, triggerName+"_update", "AFTER UPDATE OF "+quote(from), update),
+	}
+}
+
+func (db *SQLite3Dialect) CopyRowsTriggerSQL(tableName, triggerName, targetTable string, cols []string, pk string) []string {
+	quote := db.Quote
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quote(targetTable), db.QuoteColList(cols), newValues(db, cols))
+	del := fmt.Sprintf("DELETE FROM %s WHERE %s = OLD.%s", quote(targetTable), quote(pk), quote(pk))
+	return []string{
+		db.triggerSQL(tableName, triggerName+"_insert", "AFTER INSERT", insert),
+		db.triggerSQL(tableName, triggerName+"_update", "AFTER UPDATE", del+"; "+insert),
+		db.triggerSQL(tableName, triggerName+"_delete", "AFTER DELETE", del),
+	}
+}
+
+func (db *SQLite3Dialect) triggerSQL(tableName, triggerName, timing, body string) string {
+	quote := db.Quote
+	return fmt.Sprintf("CREATE TRIGGER %s %s ON %s FOR EACH ROW BEGIN %s; END", quote(triggerName), timing, quote(tableName), body)
+}
+
+func (db *SQLite3Dialect) DropIndexSQL(tableName string, index *Index) string {
+	quote := db.Quote
+	idxName := index.XName(tableName)
+	return fmt.Sprintf("DROP INDEX %v", quote(idxName))
+}
+
+// CleanDB drops every table of the database, sqlite has no schemas to recreate.
+func (db *SQLite3Dialect) CleanDB(engine *xorm.Engine) error {
+	tables, err := engine.DBMetas()
+	if err != nil {
+		return err
+	}
+	sess := engine.NewSession()
+	defer sess.Close()
+
+	for _, table := range tables {
+		if table.Name == "" || strings.HasPrefix(table.Name, "sqlite_") {
+			continue
+		}
+		if _, err := sess.Exec("DROP TABLE IF EXISTS " + db.Quote(table.Name) + ";"); err != nil {
+			return fmt.Errorf("failed to drop table %q: %w", table.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// TruncateDBTables deletes the rows of all the tables and resets the autoincrement sequences.
+func (db *SQLite3Dialect) TruncateDBTables(engine *xorm.Engine) error {
+	tables, err := engine.DBMetas()
+	if err != nil {
+		return err
+	}
+	sess := engine.NewSession()
+	defer sess.Close()
+
+	for _, table := range tables {
+		switch table.Name {
+		case "":
+			continue
+		case "migration_log":
+			continue
+		default:
+			if _, err := sess.Exec(fmt.Sprintf("DELETE FROM %v;", db.Quote(table.Name))); err != nil {
+				return fmt.Errorf("failed to truncate table %q: %w", table.Name, err)
+			}
+		}
+	}
+
+	if _, err := sess.Exec("UPDATE sqlite_sequence SET seq = 0;"); err != nil {
+		// sqlite_sequence only exists once a table with an autoincrement column has been created,
+		// sqlite returns a generic error code so the message is the only thing to discriminate on
+		if err.Error() != "no such table: sqlite_sequence" {
+			return fmt.Errorf("failed to cleanup sqlite_sequence: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (db *SQLite3Dialect) isThisError(err error, errcode int) bool {
+	var driverErr sqlite3.Error
+	if errors.As(err, &driverErr) {
+		if int(driverErr.ExtendedCode) == errcode {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (db *SQLite3Dialect) ErrorMessage(err error) string {
+	var driverErr sqlite3.Error
+	if errors.As(err, &driverErr) {
+		return driverErr.Error()
+	}
+	return ""
+}
+
+func (db *SQLite3Dialect) IsUniqueConstraintViolation(err error) bool {
+	return db.isThisError(err, int(sqlite3.ErrConstraintUnique)) || db.isThisError(err, int(sqlite3.ErrConstraintPrimaryKey))
+}
+
+// IsDeadlock reports busy and locked errors, sqlite has no deadlock detection of its own
+// but these are the errors raised when two connections compete for the database lock.
+func (db *SQLite3Dialect) IsDeadlock(err error) bool {
+	var driverErr sqlite3.Error
+	if errors.As(err, &driverErr) {
+		return driverErr.Code == sqlite3.ErrLocked || driverErr.Code == sqlite3.ErrBusy
+	}
+	return false
+}
+
+// UpsertSQL returns the upsert sql statement for SQLite dialect
+func (db *SQLite3Dialect) UpsertSQL(tableName string, keyCols, updateCols []string) string {
+	str, _ := db.UpsertMultipleSQL(tableName, keyCols, updateCols, 1)
+	return str
+}
+
+// UpsertMultipleSQL returns the upsert sql statement for SQLite dialect
+func (db *SQLite3Dialect) UpsertMultipleSQL(tableName string, keyCols, updateCols []string, count int) (string, error) {
+	if count < 1 {
+		return "", fmt.Errorf("upsert statement must have count >= 1. Got %v", count)
+	}
+	columnsStr := strings.Builder{}
+	onConflictStr := strings.Builder{}
+	colPlaceHoldersStr := strings.Builder{}
+	setStr := strings.Builder{}
+
+	const separator = ", "
+	separatorVar := separator
+	for i, c := range updateCols {
+		if i == len(updateCols)-1 {
+			separatorVar = ""
+		}
+
+		columnsStr.WriteString(fmt.Sprintf("%s%s", db.Quote(c), separatorVar))
+		colPlaceHoldersStr.WriteString(fmt.Sprintf("?%s", separatorVar))
+		setStr.WriteString(fmt.Sprintf("%s=excluded.%s%s", db.Quote(c), db.Quote(c), separatorVar))
+	}
+
+	separatorVar = separator
+	for i, c := range keyCols {
+		if i == len(keyCols)-1 {
+			separatorVar = ""
+		}
+		onConflictStr.WriteString(fmt.Sprintf("%s%s", db.Quote(c), separatorVar))
+	}
+
+	valuesStr := strings.Builder{}
+	separatorVar = separator
+	colPlaceHolders := colPlaceHoldersStr.String()
+	for i := 0; i < count; i++ {
+		if i == count-1 {
+			separatorVar = ""
+		}
+		valuesStr.WriteString(fmt.Sprintf("(%s)%s", colPlaceHolders, separatorVar))
+	}
+
+	s := fmt.Sprintf(`INSERT INTO %s (%s) VALUES %s ON CONFLICT(%s) DO UPDATE SET %s;`,
+		tableName,
+		columnsStr.String(),
+		valuesStr.String(),
+		onConflictStr.String(),
+		setStr.String(),
+	)
+
+	return s, nil
+}
+
+func (db *SQLite3Dialect) GetDBName(dsn string) (string, error) {
+	// the database is identified by its file, strip the uri scheme and the query parameters
+	name := strings.TrimPrefix(dsn, "file:")
+	if i := strings.Index(name, "?"); i >= 0 {
+		name = name[:i]
+	}
+	return name, nil
+}
@@ -0,0 +1,255 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"xorm.io/xorm"
+)
+
+var sqliteCreateTableRe = regexp.MustCompile("(?is)^\\s*CREATE\\s+TABLE\\s+(IF\\s+NOT\\s+EXISTS\\s+)?(`[^`]+`|\"[^\"]+\"|\\[[^]]+]|\\S+)")
+
+// recreateSQLiteTable changes a table the way sqlite's ALTER TABLE cannot, see https://www.sqlite.org/lang_altertable.html#otheralter.
+// rewrite gets the CREATE TABLE statement of the table and returns the changed one, the table is then
+// created anew from it and the data of the columns present in both is copied over. Foreign keys are not enforced
+// meanwhile, so the connection is pinned to run the pragmas outside of the transaction, they are checked before committing.
+func recreateSQLiteTable(engine *xorm.Engine, tableName string, rewrite func(createSQL string) (string, error)) (err error) {
+	ctx := context.Background()
+	conn, err := engine.DB().DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	var foreignKeys bool
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return err
+	}
+	if foreignKeys {
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return err
+		}
+		defer func() {
+			if _, restoreErr := conn.ExecContext(ctx, "PRAGMA foreign_keys = ON"); restoreErr != nil && err == nil {
+				err = restoreErr
+			}
+		}()
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var createSQL string
+	if err := tx.QueryRowContext(ctx, "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", tableName).Scan(&createSQL); err != nil {
+		return fmt.Errorf("failed to read the definition of table %s: %w", tableName, err)
+	}
+	// the indices and triggers are dropped together with the table
+	dependents, err := queryStrings(ctx, tx, "SELECT sql FROM sqlite_master WHERE tbl_name = ? AND type IN ('index', 'trigger') AND sql IS NOT NULL", tableName)
+	if err != nil {
+		return err
+	}
+
+	newSQL, err := rewrite(createSQL)
+	if err != nil {
+		return err
+	}
+	tmpName := tableName + "_recreate_tmp"
+	if !sqliteCreateTableRe.MatchString(newSQL) {
+		return fmt.Errorf("unexpected definition of table %s: %s", tableName, newSQL)
+	}
+	newSQL = sqliteCreateTableRe.ReplaceAllLiteralString(newSQL, "CREATE TABLE `"+tmpName+"`")
+
+	if _, err := tx.ExecContext(ctx, newSQL); err != nil {
+		return err
+	}
+
+	oldCols, err := queryStrings(ctx, tx, "SELECT name FROM pragma_table_info(?)", tableName)
+	if err != nil {
+		return err
+	}
+	newCols, err := queryStrings(ctx, tx, "SELECT name FROM pragma_table_info(?)", tmpName)
+	if err != nil {
+		return err
+	}
+	copied := make([]string, 0, len(newCols))
+	for _, col := range newCols {
+		for _, oldCol := range oldCols {
+			if oldCol == col {
+				copied = append(copied, "`"+col+"`")
+			}
+		}
+	}
+
+	statements := []string{
+		fmt.Sprintf("INSERT INTO `%s` (%s) SELECT %s FROM `%s`", tmpName, strings.Join(copied, ", "), strings.Join(copied, ", "), tableName),
+		fmt.Sprintf("DROP TABLE `%s`", tableName),
+		fmt.Sprintf("ALTER TABLE `%s` RENAME TO `%s`", tmpName, tableName),
+	}
+	for _, statement := range append(statements, dependents...) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	violations, err := queryStrings(ctx, tx, "SELECT \"table\" FROM pragma_foreign_key_check(?)", tableName)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("recreating table %s violates %d foreign key constraints", tableName, len(violations))
+	}
+
+	return tx.Commit()
+}
+
+func queryStrings(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := make([]string, 0)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, rows.Err()
+}
+
+// sqliteDefinitions splits a CREATE TABLE statement into the part before the opening parenthesis, the column
+// and constraint definitions, and the part after the closing parenthesis. Parentheses and commas within
+// string literals and quoted identifiers, 'a', "a", `a` or [a], are not counted. A quote within them is
+// written twice, which closes and reopens them.
+func sqliteDefinitions(createSQL string) (string, []string, string, error) {
+	definitions := make([]string, 0)
+	start, depth, from := -1, 0, 0
+	// closing is the character ending the literal or identifier being scanned, 0 outside of them
+	var closing byte
+	for i := 0; i < len(createSQL); i++ {
+		c := createSQL[i]
+		if closing != 0 {
+			if c == closing {
+				closing = 0
+			}
+			continue
+		}
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			closing = c
+		case c == '[':
+			closing = ']'
+		case c == '(' && start < 0:
+			start, from = i, i+1
+		case start < 0:
+		case c == '(':
+			depth++
+		case c == ',' && depth == 0:
+			definitions = append(definitions, createSQL[from:i])
+			from = i + 1
+		case c == ')' && depth == 0:
+			definitions = append(definitions, createSQL[from:i])
+			return createSQL[:start], definitions, createSQL[i+1:], nil
+		case c == ')':
+			depth--
+		}
+	}
+	return "", nil, "", fmt.Errorf("unexpected table definition: %s", createSQL)
+}
+
+// sqliteRewriteDefinitions calls rewrite with every column and constraint definition of a CREATE TABLE statement,
+// trimmed of whitespace, and replaces it by the returned one. An empty one removes the definition.
+func sqliteRewriteDefinitions(createSQL string, rewrite func(definition string) string) (string, error) {
+	head, definitions, tail, err := sqliteDefinitions(createSQL)
+	if err != nil {
+		return "", err
+	}
+
+	rewritten := make([]string, 0, len(definitions))
+	for _, definition := range definitions {
+		trimmed := strings.TrimSpace(definition)
+		if replaced := rewrite(trimmed); replaced != "" {
+			// keep the whitespace around the definition, e.g. the line breaks of xorm
+			rewritten = append(rewritten, strings.Replace(definition, trimmed, replaced, 1))
+		}
+	}
+	return head + "(" + strings.Join(rewritten, ",") + ")" + tail, nil
+}
+
+// sqliteDefinitionName returns the unquoted column or constraint name a definition starts with.
+func sqliteDefinitionName(definition string) string {
+	fields := strings.Fields(definition)
+	if len(fields) > 1 && strings.EqualFold(fields[0], "CONSTRAINT") {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.Trim(fields[0], "`\"[]")
+}
+
+// sqliteAddConstraint adds a constraint definition after the last column or constraint of a CREATE TABLE statement.
+func sqliteAddConstraint(definition string) func(string) (string, error) {
+	return func(createSQL string) (string, error) {
+		head, definitions, tail, err := sqliteDefinitions(createSQL)
+		if err != nil {
+			return "", err
+		}
+		definitions = append(definitions, "\n"+definition)
+		return head + "(" + strings.Join(definitions, ",") + ")" + tail, nil
+	}
+}
+
+// sqliteDropConstraint removes the named constraint from a CREATE TABLE statement.
+func sqliteDropConstraint(name string) func(string) (string, error) {
+	return func(createSQL string) (string, error) {
+		found := false
+		newSQL, err := sqliteRewriteDefinitions(createSQL, func(definition string) string {
+			if strings.HasPrefix(strings.ToUpper(definition), "CONSTRAINT") && sqliteDefinitionName(definition) == name {
+				found = true
+				return ""
+			}
+			return definition
+		})
+		if err == nil && !found {
+			err = fmt.Errorf("constraint %s not found in table definition: %s", name, createSQL)
+		}
+		return newSQL, err
+	}
+}
+
+// sqliteAlterColumn replaces the definition of a column in a CREATE TABLE statement.
+func sqliteAlterColumn(name string, definition string) func(string) (string, error) {
+	return func(createSQL string) (string, error) {
+		found := false
+		newSQL, err := sqliteRewriteDefinitions(createSQL, func(current string) string {
+			if !strings.HasPrefix(strings.ToUpper(current), "CONSTRAINT") && sqliteDefinitionName(current) == name {
+				found = true
+				return definition
+			}
+			return current
+		})
+		if err == nil && !found {
+			err = fmt.Errorf("column %s not found in table definition: %s", name, createSQL)
+		}
+		return newSQL, err
+	}
+}
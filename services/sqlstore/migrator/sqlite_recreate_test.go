@@ -0,0 +1,194 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSQLiteDefinitions(t *testing.T) {
+	tests := []struct {
+		name        string
+		createSQL   string
+		head        string
+		definitions []string
+		tail        string
+	}{
+		{
+			name:        "columns and constraints",
+			createSQL:   "CREATE TABLE `t` (`a` INTEGER, `b` TEXT, CONSTRAINT `CHK_t_a` CHECK (`a` IN (1, 2))) WITHOUT ROWID",
+			head:        "CREATE TABLE `t` ",
+			definitions: []string{"`a` INTEGER", " `b` TEXT", " CONSTRAINT `CHK_t_a` CHECK (`a` IN (1, 2))"},
+			tail:        " WITHOUT ROWID",
+		},
+		{
+			name:        "quoted identifiers",
+			createSQL:   "CREATE TABLE \"t(1)\" (\"a,b\" TEXT, [c)d] INTEGER, `e(` TEXT, \"f\"\"(\" TEXT)",
+			head:        "CREATE TABLE \"t(1)\" ",
+			definitions: []string{"\"a,b\" TEXT", " [c)d] INTEGER", " `e(` TEXT", " \"f\"\"(\" TEXT"},
+		},
+		{
+			name:        "string literals",
+			createSQL:   "CREATE TABLE t (a TEXT DEFAULT 'x,(y', b TEXT DEFAULT 'it''s)', CHECK (a <> ')'))",
+			head:        "CREATE TABLE t ",
+			definitions: []string{"a TEXT DEFAULT 'x,(y'", " b TEXT DEFAULT 'it''s)'", " CHECK (a <> ')')"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head, definitions, tail, err := sqliteDefinitions(tt.createSQL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if head != tt.head || tail != tt.tail {
+				t.Errorf("head and tail are %q and %q, expected %q and %q", head, tail, tt.head, tt.tail)
+			}
+			if strings.Join(definitions, "|") != strings.Join(tt.definitions, "|") {
+				t.Errorf("definitions are %q, expected %q", definitions, tt.definitions)
+			}
+		})
+	}
+
+	for _, createSQL := range []string{
+		"CREATE TABLE t",
+		"CREATE TABLE t (a TEXT",
+		"CREATE TABLE t (a TEXT DEFAULT ')",
+		"CREATE TABLE \"t(\" ",
+	} {
+		if _, _, _, err := sqliteDefinitions(createSQL); err == nil {
+			t.Errorf("expected %q to be rejected", createSQL)
+		}
+	}
+}
+
+func TestSQLiteConstraintMigrations(t *testing.T) {
+	parent := Table{
+		Name: "parent",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+		},
+	}
+	child := Table{
+		Name: "child",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "parent_id", Type: DB_BigInt, Nullable: false},
+			{Name: "amount", Type: DB_Int, Nullable: false},
+		},
+		Indices: []*Index{{Cols: []string{"parent_id"}}},
+	}
+	fk := &ForeignKey{Cols: []string{"parent_id"}, RefTable: "parent", RefCols: []string{"id"}, OnDelete: "CASCADE"}
+	check := &Check{Name: "amount", Expr: "amount >= 0"}
+
+	engine := newTestEngine(t)
+	mg, _ := newTestMigrator(engine, func(mg *Migrator) {
+		mg.AddMigration("create parent table", NewAddTableMigration(parent))
+		mg.AddMigration("create child table", NewAddTableMigration(child))
+		mg.AddMigration("add index child.parent_id", NewAddIndexMigration(child, child.Indices[0]))
+		mg.AddMigration("insert rows", NewRawSQLMigration("INSERT INTO parent (id) VALUES (1); INSERT INTO child (id, parent_id, amount) VALUES (1, 1, 5)"))
+		mg.AddMigration("add foreign key child.parent_id", NewAddForeignKeyMigration(child, fk))
+		mg.AddMigration("add check child.amount", NewAddCheckMigration(child, check))
+	})
+	if err := mg.Start(false, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(sql string, args ...interface{}) int {
+		t.Helper()
+		var n int
+		if _, err := engine.SQL(sql, args...).Get(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	assertKept := func() {
+		t.Helper()
+		if n := count("SELECT COUNT(*) FROM child WHERE parent_id = 1 AND amount = 5"); n != 1 {
+			t.Errorf("the row of child was not kept, found %d", n)
+		}
+		if n := count("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = 'child' AND name = ?", child.Indices[0].XName("child")); n != 1 {
+			t.Errorf("the index of child was not kept")
+		}
+	}
+
+	assertKept()
+	if n := count("SELECT COUNT(*) FROM pragma_foreign_key_list('child') WHERE \"table\" = 'parent' AND on_delete = 'CASCADE'"); n != 1 {
+		t.Errorf("child has %d foreign keys to parent, expected 1", n)
+	}
+	if _, err := engine.Exec("INSERT INTO child (parent_id, amount) VALUES (1, -1)"); err == nil {
+		t.Error("the check constraint was not added")
+	}
+
+	mg, _ = newTestMigrator(engine, func(mg *Migrator) {
+		mg.AddMigration("drop check child.amount", NewDropCheckMigration(child, check))
+		mg.AddMigration("drop foreign key child.parent_id", NewDropForeignKeyMigration(child, fk))
+	})
+	if err := mg.Start(false, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	assertKept()
+	if n := count("SELECT COUNT(*) FROM pragma_foreign_key_list('child')"); n != 0 {
+		t.Errorf("child has %d foreign keys after dropping it", n)
+	}
+	if _, err := engine.Exec("INSERT INTO child (parent_id, amount) VALUES (1, -1)"); err != nil {
+		t.Errorf("the check constraint was not dropped: %v", err)
+	}
+}
+
+func TestSQLiteAlterAndDropColumn(t *testing.T) {
+	table := Table{
+		Name: "item",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: true},
+			{Name: "notes", Type: DB_Text, Nullable: true},
+		},
+		Indices: []*Index{{Cols: []string{"name"}}},
+	}
+	nullable := table.Columns[1]
+	notNull := &Column{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false, Default: "''"}
+
+	engine := newTestEngine(t)
+	mg, _ := newTestMigrator(engine, func(mg *Migrator) {
+		mg.AddMigration("create item table", NewAddTableMigration(table))
+		mg.AddMigration("add index item.name", NewAddIndexMigration(table, table.Indices[0]))
+		mg.AddMigration("insert rows", NewRawSQLMigration("INSERT INTO item (id, name, notes) VALUES (1, 'a', 'n')"))
+		mg.AddMigration("set name not null", NewAlterColumnMigration(table, notNull).From(nullable))
+		mg.AddMigration("drop column notes", NewDropColumnMigration(table, table.Columns[2]))
+	})
+	if err := mg.Start(false, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(sql string, args ...interface{}) int {
+		t.Helper()
+		var n int
+		if _, err := engine.SQL(sql, args...).Get(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := count("SELECT COUNT(*) FROM item WHERE id = 1 AND name = 'a'"); n != 1 {
+		t.Errorf("the row of item was not kept")
+	}
+	if n := count("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?", table.Indices[0].XName("item")); n != 1 {
+		t.Errorf("the index of item was not kept")
+	}
+	if n := count("SELECT COUNT(*) FROM pragma_table_info('item') WHERE name = 'name' AND \"notnull\" = 1"); n != 1 {
+		t.Errorf("name was not made NOT NULL")
+	}
+	if n := count("SELECT COUNT(*) FROM pragma_table_info('item') WHERE name = 'notes'"); n != 0 {
+		t.Errorf("notes was not dropped")
+	}
+
+	// reverting adds notes back and makes name nullable again
+	if err := mg.RollbackTo("insert rows", false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := count("SELECT COUNT(*) FROM pragma_table_info('item') WHERE (name = 'name' AND \"notnull\" = 0) OR name = 'notes'"); n != 2 {
+		t.Errorf("the rollback did not restore name and notes")
+	}
+	if n := count("SELECT COUNT(*) FROM item WHERE id = 1 AND name = 'a'"); n != 1 {
+		t.Errorf("the row of item was not kept by the rollback")
+	}
+}
@@ -0,0 +1,131 @@
+package migrator
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// MigrationState is the state of a registered migration according to the migration log.
+type MigrationState string
+
+const (
+	MigrationApplied MigrationState = "applied"
+	MigrationPending MigrationState = "pending"
+	// MigrationFailed migrations have only failed attempts in the log, they run again on the next start.
+	MigrationFailed MigrationState = "failed"
+)
+
+type MigrationStatus struct {
+	// Scope is the scope of the migrator the migration is registered to, empty for the default one
+	Scope string         `json:"scope,omitempty"`
+	ID    string         `json:"id"`
+	State MigrationState `json:"state"`
+	// Timestamp is the time of the successful run, or of the last attempt of a failed migration
+	Timestamp time.Time `json:"timestamp"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Status returns the state of every registered migration, in the order they run.
+func (mg *Migrator) Status() ([]*MigrationStatus, error) {
+	logItems := make([]MigrationLog, 0)
+
+	exists, err := mg.DBEngine.IsTableExist(mg.tableName)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "failed to check table existence", err)
+	}
+	if exists {
+		if err := mg.refreshLogHasChecksum(); err != nil {
+			return nil, err
+		}
+		sess := mg.DBEngine.NewSession()
+		defer sess.Close()
+		if err := mg.logTable(sess).Asc("id").Find(&logItems); err != nil {
+			return nil, err
+		}
+	}
+
+	applied := make(map[string]MigrationLog)
+	failed := make(map[string]MigrationLog)
+	for _, logItem := range logItems {
+		if logItem.Success {
+			applied[logItem.MigrationID] = logItem
+		} else {
+			failed[logItem.MigrationID] = logItem
+		}
+	}
+
+	result := make([]*MigrationStatus, 0, len(mg.migrations))
+	for _, m := range mg.migrations {
+		status := &MigrationStatus{Scope: mg.scope, ID: m.Id(), State: MigrationPending}
+		if logItem, ok := applied[m.Id()]; ok {
+			status.State = MigrationApplied
+			status.Timestamp = logItem.Timestamp
+		} else if logItem, ok := failed[m.Id()]; ok {
+			status.State = MigrationFailed
+			status.Timestamp = logItem.Timestamp
+			status.Error = logItem.Error
+		}
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+// WriteStatusText writes the statuses as a table followed by a summary line, and a summary line
+// per scope when the statuses are of several scopes.
+func WriteStatusText(w io.Writer, statuses []*MigrationStatus) error {
+	counts := make(map[MigrationState]int)
+	scopes := make([]string, 0)
+	scopeCounts := make(map[string]map[MigrationState]int)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATE\tTIMESTAMP\tID")
+	for _, s := range statuses {
+		counts[s.State]++
+		if _, ok := scopeCounts[s.Scope]; !ok {
+			scopes = append(scopes, s.Scope)
+			scopeCounts[s.Scope] = make(map[MigrationState]int)
+		}
+		scopeCounts[s.Scope][s.State]++
+		timestamp := "-"
+		if !s.Timestamp.IsZero() {
+			timestamp = s.Timestamp.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.State, timestamp, scopedID(s.Scope, s.ID))
+		if s.Error != "" {
+			fmt.Fprintf(tw, "\t\terror: %s\n", s.Error)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "\napplied: %d, pending: %d, failed: %d\n",
+		counts[MigrationApplied], counts[MigrationPending], counts[MigrationFailed]); err != nil {
+		return err
+	}
+	if len(scopes) < 2 {
+		return nil
+	}
+	for _, scope := range scopes {
+		name := scope
+		if name == "" {
+			name = "default"
+		}
+		c := scopeCounts[scope]
+		if _, err := fmt.Fprintf(w, "  %s: applied: %d, pending: %d, failed: %d\n",
+			name, c[MigrationApplied], c[MigrationPending], c[MigrationFailed]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scopedID prefixes the id of a migration with its scope, the same id may be registered in several scopes.
+func scopedID(scope, id string) string {
+	if scope == "" {
+		return id
+	}
+	return scope + "/" + id
+}
@@ -9,6 +9,8 @@ import (
 
 const (
 	Postgres = "postgres"
+	SQLite   = "sqlite3"
+	MySQL    = "mysql"
 )
 
 type Migration interface {
@@ -27,6 +29,27 @@ type CodeMigration interface {
 	Exec(sess *xorm.Session, migrator *Migrator) error
 }
 
+// ReversibleMigration is a migration that can be reverted by Migrator.RollbackTo.
+// An empty DownSQL means the migration cannot be reverted for that dialect.
+type ReversibleMigration interface {
+	Migration
+	DownSQL(dialect Dialect) string
+}
+
+// NonTransactionalMigration is a migration that cannot run inside a transaction for some dialects,
+// e.g. CREATE INDEX CONCURRENTLY on Postgres. It runs, and is reverted, outside of a transaction
+// and logged afterwards, so its SQL must be safe to run again if logging fails.
+type NonTransactionalMigration interface {
+	Migration
+	NoTransaction(dialect Dialect) bool
+}
+
+// ReversibleCodeMigration is a code migration that can be reverted by Migrator.RollbackTo.
+type ReversibleCodeMigration interface {
+	CodeMigration
+	Down(sess *xorm.Session, migrator *Migrator) error
+}
+
 type SQLType string
 
 type ColumnType string
@@ -40,6 +63,10 @@ type Table struct {
 	Columns     []*Column
 	PrimaryKeys []string
 	Indices     []*Index
+	ForeignKeys []*ForeignKey
+	Checks      []*Check
+	// Comment is set on MySQL and Postgres, sqlite has no comments
+	Comment string
 }
 
 const (
@@ -51,6 +78,12 @@ type Index struct {
 	Name string
 	Type int
 	Cols []string
+	// Concurrent builds the index without blocking writes: CONCURRENTLY on Postgres, which makes
+	// the migration run outside of a transaction, and an online build on MySQL
+	Concurrent bool
+	// Where makes a partial index, e.g. "is_service_account = false". MySQL has no partial indexes
+	// and indexes every row, so a unique partial index panics there
+	Where string
 }
 
 func (index *Index) XName(tableName string) string {
@@ -68,6 +101,49 @@ func (index *Index) XName(tableName string) string {
 	return index.Name
 }
 
+// Referential actions of a foreign key
+const (
+	Cascade    = "CASCADE"
+	SetNull    = "SET NULL"
+	SetDefault = "SET DEFAULT"
+	Restrict   = "RESTRICT"
+	NoAction   = "NO ACTION"
+)
+
+// ForeignKey references the primary key, or a unique index, of another table.
+type ForeignKey struct {
+	Name     string
+	Cols     []string
+	RefTable string
+	RefCols  []string
+	// OnDelete is the referential action run when the referenced row is deleted, e.g. Cascade
+	OnDelete string
+}
+
+func (fk *ForeignKey) XName(tableName string) string {
+	if fk.Name == "" {
+		fk.Name = strings.Join(fk.Cols, "_")
+	}
+
+	if !strings.HasPrefix(fk.Name, "FK_") {
+		return fmt.Sprintf("FK_%v_%v", tableName, fk.Name)
+	}
+	return fk.Name
+}
+
+// Check is a CHECK constraint, Expr is the SQL condition every row has to fulfill.
+type Check struct {
+	Name string
+	Expr string
+}
+
+func (c *Check) XName(tableName string) string {
+	if !strings.HasPrefix(c.Name, "CK_") {
+		return fmt.Sprintf("CK_%v_%v", tableName, c.Name)
+	}
+	return c.Name
+}
+
 var (
 	DB_Bit       = "BIT"
 	DB_TinyInt   = "TINYINT"
@@ -0,0 +1,61 @@
+package sqlstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// OutboxEvent is an event queued with DBSession.PublishAfterCommit and stored in the
+// event_outbox table by the transaction that produced it, until a relay delivers it.
+type OutboxEvent struct {
+	ID            int64     `xorm:"pk autoincr 'id'"`
+	EventType     string    `xorm:"event_type"`
+	Payload       string    `xorm:"payload"`
+	Created       time.Time `xorm:"created"`
+	Delivered     bool      `xorm:"delivered"`
+	DeliveredAt   time.Time `xorm:"delivered_at"`
+	Attempts      int       `xorm:"attempts"`
+	LastError     string    `xorm:"last_error"`
+	NextAttemptAt time.Time `xorm:"next_attempt_at"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "event_outbox"
+}
+
+// OutboxEventType returns the name an event is stored under in the event_outbox table.
+func OutboxEventType(msg interface{}) string {
+	return getTypeName(msg)
+}
+
+// writeOutbox stores the events of the session in the event_outbox table, it must run
+// before the transaction of the session is committed.
+func writeOutbox(sess *DBSession, events []interface{}) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]*OutboxEvent, 0, len(events))
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %T for the outbox: %w", e, err)
+		}
+		rows = append(rows, &OutboxEvent{
+			EventType:     OutboxEventType(e),
+			Payload:       string(payload),
+			Created:       now,
+			NextAttemptAt: now,
+		})
+	}
+
+	sess.UseBool("delivered")
+	for _, row := range rows {
+		if _, err := sess.Insert(row); err != nil {
+			return fmt.Errorf("failed to write event %s to the outbox: %w", row.EventType, err)
+		}
+	}
+	return nil
+}
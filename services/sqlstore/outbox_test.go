@@ -0,0 +1,56 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrations"
+)
+
+func TestWriteOutbox(t *testing.T) {
+	ss := InitTestDB(t, &migrations.OxygenMigrations{})
+	ss.cfg.EventOutboxEnabled = true
+	ctx := context.Background()
+
+	err := ss.InTransaction(ctx, func(ctx context.Context) error {
+		return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+			sess.PublishAfterCommit(&testItemCreated{Name: "a"})
+			sess.PublishAfterCommit(&testItemCreated{Name: "b"})
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := make([]*OutboxEvent, 0)
+	if err := ss.engine.Asc("id").Find(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("the commit wrote %d outbox rows, expected 2", len(rows))
+	}
+	for i, name := range []string{"a", "b"} {
+		want := `{"Name":"` + name + `"}`
+		if rows[i].EventType != OutboxEventType(&testItemCreated{}) || rows[i].Payload != want || rows[i].Delivered {
+			t.Errorf("outbox row %d is %+v, expected an undelivered %s", i, rows[i], want)
+		}
+	}
+
+	failed := errors.New("failed")
+	err = ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		sess.PublishAfterCommit(&testItemCreated{Name: "c"})
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("WithTransactionalDbSession returned %v, expected the error of the callback", err)
+	}
+	count, err := ss.engine.Count(&OutboxEvent{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("the rollback left %d outbox rows, expected the 2 committed ones", count)
+	}
+}
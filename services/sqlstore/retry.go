@@ -0,0 +1,67 @@
+package sqlstore
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	retryMinBackoff = 10 * time.Millisecond
+	retryMaxBackoff = time.Second
+)
+
+// retryCounters counts the retries made on deadlock, serialization and database locked errors.
+type retryCounters struct {
+	transactions atomic.Int64
+	queries      atomic.Int64
+	exhausted    atomic.Int64
+}
+
+// GetUsageStats returns the retry counts of the store since it was started.
+func (ss *SQLStore) GetUsageStats(_ context.Context) map[string]interface{} {
+	return map[string]interface{}{
+		"stats.database.transaction_retries.count": ss.retries.transactions.Load(),
+		"stats.database.query_retries.count":       ss.retries.queries.Load(),
+		"stats.database.retries_exhausted.count":   ss.retries.exhausted.Load(),
+	}
+}
+
+// retryOnLocks calls fn until it succeeds, fails with an error the dialect does not consider
+// retryable or maxRetries retries have been made. Retries are delayed by a jittered exponential backoff.
+func (ss *SQLStore) retryOnLocks(ctx context.Context, maxRetries int, counter *atomic.Int64, fn func() error) error {
+	for retry := 0; ; retry++ {
+		err := fn()
+		if err == nil || !ss.Dialect.IsRetryableError(err) {
+			return err
+		}
+		if retry >= maxRetries {
+			if maxRetries > 0 {
+				ss.retries.exhausted.Add(1)
+			}
+			return err
+		}
+
+		counter.Add(1)
+		delay := retryBackoff(retry)
+		ss.log.Warnf("database error is retryable, retrying in %s (retry %d of %d): %v", delay, retry+1, maxRetries, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryBackoff returns a random delay between half the minimum backoff and an exponentially growing upper bound,
+// capped at retryMaxBackoff, so that competing sessions do not retry in lockstep.
+func retryBackoff(retry int) time.Duration {
+	ceiling := retryMinBackoff << retry
+	if ceiling <= 0 || ceiling > retryMaxBackoff {
+		ceiling = retryMaxBackoff
+	}
+	floor := retryMinBackoff / 2
+	return floor + time.Duration(rand.Int63n(int64(ceiling-floor)+1))
+}
@@ -0,0 +1,20 @@
+package sqlstore
+
+import (
+	"testing"
+)
+
+func TestRetryBackoffBounds(t *testing.T) {
+	for retry := 0; retry < 70; retry++ {
+		ceiling := retryMinBackoff << retry
+		if ceiling <= 0 || ceiling > retryMaxBackoff {
+			ceiling = retryMaxBackoff
+		}
+		for i := 0; i < 100; i++ {
+			delay := retryBackoff(retry)
+			if delay < retryMinBackoff/2 || delay > ceiling {
+				t.Fatalf("retry %d: delay %s is outside [%s, %s]", retry, delay, retryMinBackoff/2, ceiling)
+			}
+		}
+	}
+}
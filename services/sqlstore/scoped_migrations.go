@@ -0,0 +1,97 @@
+package sqlstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+)
+
+// ScopedMigrations are the migrations of a module, logged in <scope>_migration_log apart from the
+// migrations passed to ProvideService, which always run first.
+type ScopedMigrations interface {
+	DatabaseMigrator
+	// Scope names the migration log of the migrations, it has to be unique
+	Scope() string
+	// DependsOn returns the scopes whose migrations have to run before, e.g. for a foreign key to their tables
+	DependsOn() []string
+}
+
+// RegisterMigrations registers the migrations of a module, they run with the next Migrate.
+// The scopes and their dependencies are only checked then, so modules may register in any order.
+func (ss *SQLStore) RegisterMigrations(migrations ScopedMigrations) {
+	ss.scopedMigrations = append(ss.scopedMigrations, migrations)
+}
+
+// migrators returns the default migrator followed by a migrator per registered scope, every scope
+// comes after the scopes it depends on and otherwise in the order they were registered.
+func (ss *SQLStore) migrators() ([]*migrator.Migrator, error) {
+	ordered, err := sortScopedMigrations(ss.scopedMigrations)
+	if err != nil {
+		return nil, err
+	}
+
+	migrators := []*migrator.Migrator{ss.newMigrator()}
+	for _, migrations := range ordered {
+		mg := migrator.NewScopedMigrator(ss.engine, migrations.Scope())
+		mg.FailOnDrift = ss.dbCfg.FailOnMigrationDrift
+		mg.AddCreateMigration()
+		migrations.AddMigration(mg)
+		migrators = append(migrators, mg)
+	}
+	return migrators, nil
+}
+
+// sortScopedMigrations orders the migrations so that the dependencies of a scope come before it.
+func sortScopedMigrations(registered []ScopedMigrations) ([]ScopedMigrations, error) {
+	byScope := make(map[string]ScopedMigrations, len(registered))
+	for _, migrations := range registered {
+		scope := migrations.Scope()
+		if scope == "" {
+			return nil, fmt.Errorf("scoped migrations %T have an empty scope", migrations)
+		}
+		if _, ok := byScope[scope]; ok {
+			return nil, fmt.Errorf("migrations of scope %s are registered twice", scope)
+		}
+		byScope[scope] = migrations
+	}
+
+	ordered := make([]ScopedMigrations, 0, len(registered))
+	done := make(map[string]bool, len(registered))
+	// visiting is the path of scopes being visited, a scope found in it again is a cycle
+	visiting := make([]string, 0)
+
+	var visit func(scope string) error
+	visit = func(scope string) error {
+		if done[scope] {
+			return nil
+		}
+		for i, s := range visiting {
+			if s == scope {
+				return fmt.Errorf("migration scopes depend on each other: %s", strings.Join(append(visiting[i:], scope), " -> "))
+			}
+		}
+
+		visiting = append(visiting, scope)
+		for _, dependency := range byScope[scope].DependsOn() {
+			if _, ok := byScope[dependency]; !ok {
+				return fmt.Errorf("migrations of scope %s depend on scope %s, which is not registered", scope, dependency)
+			}
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		visiting = visiting[:len(visiting)-1]
+
+		done[scope] = true
+		ordered = append(ordered, byScope[scope])
+		return nil
+	}
+
+	for _, migrations := range registered {
+		if err := visit(migrations.Scope()); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,119 @@
+package sqlstore
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+)
+
+// testScopedMigrations creates the table <scope>_item, with a foreign key to the table of every dependency.
+type testScopedMigrations struct {
+	scope     string
+	dependsOn []string
+}
+
+func (m *testScopedMigrations) Scope() string {
+	return m.scope
+}
+
+func (m *testScopedMigrations) DependsOn() []string {
+	return m.dependsOn
+}
+
+func (m *testScopedMigrations) AddMigration(mg *migrator.Migrator) {
+	sql := fmt.Sprintf("CREATE TABLE %s_item (id INTEGER PRIMARY KEY", m.scope)
+	for _, dependency := range m.dependsOn {
+		sql += fmt.Sprintf(", %[1]s_id INTEGER REFERENCES %[1]s_item (id)", dependency)
+	}
+	mg.AddMigration(fmt.Sprintf("create %s_item table", m.scope), migrator.NewRawSQLMigration(sql+")"))
+}
+
+func TestSortScopedMigrations(t *testing.T) {
+	scoped := func(scope string, dependsOn ...string) ScopedMigrations {
+		return &testScopedMigrations{scope: scope, dependsOn: dependsOn}
+	}
+	tests := []struct {
+		name       string
+		registered []ScopedMigrations
+		want       string
+		err        string
+	}{
+		{
+			name:       "registration order",
+			registered: []ScopedMigrations{scoped("a"), scoped("b"), scoped("c")},
+			want:       "a, b, c",
+		},
+		{
+			name:       "dependencies first",
+			registered: []ScopedMigrations{scoped("c", "b"), scoped("a"), scoped("b", "a")},
+			want:       "a, b, c",
+		},
+		{
+			name:       "cycle",
+			registered: []ScopedMigrations{scoped("a", "b"), scoped("b", "a")},
+			err:        "migration scopes depend on each other: a -> b -> a",
+		},
+		{
+			name:       "missing dependency",
+			registered: []ScopedMigrations{scoped("a", "b")},
+			err:        "migrations of scope a depend on scope b, which is not registered",
+		},
+		{
+			name:       "empty scope",
+			registered: []ScopedMigrations{scoped("")},
+			err:        "have an empty scope",
+		},
+		{
+			name:       "duplicate scope",
+			registered: []ScopedMigrations{scoped("a"), scoped("a")},
+			err:        "migrations of scope a are registered twice",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered, err := sortScopedMigrations(tt.registered)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("sortScopedMigrations returned %v, expected an error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			scopes := make([]string, 0, len(ordered))
+			for _, m := range ordered {
+				scopes = append(scopes, m.Scope())
+			}
+			if got := strings.Join(scopes, ", "); got != tt.want {
+				t.Errorf("the scopes are ordered %s, expected %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunScopedMigrations(t *testing.T) {
+	ss := InitTestDB(t, &testMigrations{},
+		&testScopedMigrations{scope: "team", dependsOn: []string{"org"}},
+		&testScopedMigrations{scope: "org"},
+	)
+
+	for _, table := range []string{"org_item", "org_migration_log", "team_item", "team_migration_log"} {
+		exists, err := ss.GetEngine().IsTableExist(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !exists {
+			t.Errorf("table %s does not exist", table)
+		}
+	}
+	applied, err := ss.GetEngine().Table("team_migration_log").Where("migration_id = ?", "create team_item table").Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 1 {
+		t.Errorf("create team_item table is not logged in team_migration_log")
+	}
+}
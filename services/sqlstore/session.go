@@ -20,6 +20,8 @@ func (sess *DBSession) publishAfterCommit(msg interface{}) {
 	sess.events = append(sess.events, msg)
 }
 
+// PublishAfterCommit queues an event that is published on the bus once the transaction
+// of the session is committed, the events are dropped if the transaction is rolled back.
 func (sess *DBSession) PublishAfterCommit(msg interface{}) {
 	sess.events = append(sess.events, msg)
 }
@@ -34,11 +36,12 @@ func startSessionOrUseExisting(ctx context.Context, engine *xorm.Engine, beginTr
 		return sess, false, nil
 	}
 
-	newSess := &DBSession{Session: engine.NewSession(), transactionOpen: beginTran}
+	newSess := &DBSession{Session: engine.NewSession().Context(ctx), transactionOpen: beginTran}
 
 	if beginTran {
 		err := newSess.Begin()
 		if err != nil {
+			newSess.Close()
 			return nil, false, err
 		}
 	}
@@ -49,31 +52,39 @@ func startSessionOrUseExisting(ctx context.Context, engine *xorm.Engine, beginTr
 // WithDbSession calls the callback with the session in the context (if exists).
 // Otherwise it creates a new one that is closed upon completion.
 // A session is stored in the context if sqlstore.InTransaction() has been previously called with the same context (and it's not committed/rolledback yet).
-// In case of sqlite3.ErrLocked or sqlite3.ErrBusy failure it will be retried at most five times before giving up.
+// In case of a deadlock or database locked (sqlite3.ErrLocked, sqlite3.ErrBusy) failure of a session it created,
+// the callback is retried at most DatabaseConfig.QueryRetries times before giving up.
 func (ss *SQLStore) WithDbSession(ctx context.Context, callback DBTransactionFunc) error {
 	return ss.withDbSession(ctx, ss.engine, callback)
 }
 
 // WithNewDbSession calls the callback with a new session that is closed upon completion.
-// In case of sqlite3.ErrLocked or sqlite3.ErrBusy failure it will be retried at most five times before giving up.
+// In case of a deadlock or database locked (sqlite3.ErrLocked, sqlite3.ErrBusy) failure
+// the callback is retried at most DatabaseConfig.QueryRetries times before giving up.
 func (ss *SQLStore) WithNewDbSession(ctx context.Context, callback DBTransactionFunc) error {
-	sess := &DBSession{Session: ss.engine.NewSession(), transactionOpen: false}
+	sess := &DBSession{Session: ss.engine.NewSession().Context(ctx), transactionOpen: false}
 	defer sess.Close()
-	return nil
+	return ss.retryOnLocks(ctx, ss.dbCfg.QueryRetries, &ss.retries.queries, func() error {
+		return callback(sess)
+	})
 }
 
 func (ss *SQLStore) withDbSession(ctx context.Context, engine *xorm.Engine, callback DBTransactionFunc) error {
-	sess, isNew, _ := startSessionOrUseExisting(ctx, engine, false)
-	if isNew {
-		defer func() {
-			sess.Close()
-		}()
-	}
-	err := callback(sess)
+	sess, isNew, err := startSessionOrUseExisting(ctx, engine, false)
 	if err != nil {
 		return err
 	}
-	return nil
+	if !isNew {
+		// a failed statement aborts the transaction of a reused session, the outer scope retries it as a whole
+		return callback(sess)
+	}
+
+	defer func() {
+		sess.Close()
+	}()
+	return ss.retryOnLocks(ctx, ss.dbCfg.QueryRetries, &ss.retries.queries, func() error {
+		return callback(sess)
+	})
 }
 
 func (sess *DBSession) InsertId(bean interface{}, dialect migrator.Dialect) error {
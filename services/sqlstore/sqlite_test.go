@@ -0,0 +1,130 @@
+package sqlstore
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+	"github.com/Suj8K/oxygen-go/setting"
+)
+
+// testMigrations creates the test_item table, with a unique name.
+type testMigrations struct{}
+
+func (*testMigrations) AddMigration(mg *migrator.Migrator) {
+	mg.AddCreateMigration()
+	table := migrator.Table{
+		Name: "test_item",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "name", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "value", Type: migrator.DB_Int, Nullable: false, Default: "0"},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"name"}, Type: migrator.UniqueIndex},
+		},
+	}
+	mg.AddMigration("create test_item table", migrator.NewAddTableMigration(table))
+	mg.AddMigration("add unique index test_item.name", migrator.NewAddIndexMigration(table, table.Indices[0]))
+}
+
+func TestSQLiteConnectionString(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: ":memory:", want: "file::memory:?cache=shared&mode=memory"},
+		{path: "/var/lib/oxygen/oxygen.db", want: "file:/var/lib/oxygen/oxygen.db?cache=private&mode=rwc"},
+	}
+	for _, tt := range tests {
+		cfg := setting.NewCfg()
+		cfg.Raw.Section("database").Key("type").SetValue(migrator.SQLite)
+		cfg.Raw.Section("database").Key("path").SetValue(tt.path)
+
+		ss := &SQLStore{cfg: cfg}
+		got, err := ss.buildConnectionString()
+		if err != nil {
+			t.Fatalf("path %s: %v", tt.path, err)
+		}
+		if !strings.HasPrefix(got, tt.want) {
+			t.Errorf("path %s: connection string is %q, expected it to start with %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSQLiteRelativePathIsInDataPath(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.DataPath = t.TempDir()
+	cfg.Raw.Section("database").Key("type").SetValue(migrator.SQLite)
+	cfg.Raw.Section("database").Key("path").SetValue("oxygen.db")
+
+	ss := &SQLStore{cfg: cfg}
+	got, err := ss.buildConnectionString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "file:" + filepath.Join(cfg.DataPath, "oxygen.db") + "?"; !strings.HasPrefix(got, want) {
+		t.Errorf("connection string is %q, expected it to start with %q", got, want)
+	}
+}
+
+func TestSQLiteDialect(t *testing.T) {
+	ss := InitTestDB(t, &testMigrations{})
+	engine, dialect := ss.GetEngine(), ss.GetDialect()
+
+	if _, err := engine.Exec("INSERT INTO test_item (name, value) VALUES (?, ?)", "a", 1); err != nil {
+		t.Fatal(err)
+	}
+	_, err := engine.Exec("INSERT INTO test_item (name, value) VALUES (?, ?)", "a", 2)
+	if !dialect.IsUniqueConstraintViolation(err) {
+		t.Errorf("inserting a duplicate name failed with %v, expected a unique constraint violation", err)
+	}
+	if dialect.IsDeadlock(err) {
+		t.Errorf("a unique constraint violation is classified as a deadlock")
+	}
+
+	upsert := dialect.UpsertSQL("test_item", []string{"name"}, []string{"name", "value"})
+	if _, err := engine.Exec(upsert, "a", 3); err != nil {
+		t.Fatalf("upsert failed: %v", err)
+	}
+	var value int
+	if _, err := engine.SQL("SELECT value FROM test_item WHERE name = ?", "a").Get(&value); err != nil {
+		t.Fatal(err)
+	}
+	if value != 3 {
+		t.Errorf("value is %d after the upsert, expected 3", value)
+	}
+
+	exists := func(sql string, args []interface{}) bool {
+		t.Helper()
+		rows, err := engine.DB().Query(sql, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			_ = rows.Close()
+		}()
+		return rows.Next()
+	}
+	if !exists(dialect.IndexCheckSQL("test_item", "UQE_test_item_name")) {
+		t.Error("IndexCheckSQL does not find UQE_test_item_name")
+	}
+	if exists(dialect.IndexCheckSQL("test_item", "IDX_test_item_value")) {
+		t.Error("IndexCheckSQL finds an index that does not exist")
+	}
+	if !exists(dialect.ColumnCheckSQL("test_item", "value")) {
+		t.Error("ColumnCheckSQL does not find column value")
+	}
+
+	if err := dialect.TruncateDBTables(engine); err != nil {
+		t.Fatal(err)
+	}
+	count, err := engine.Table("test_item").Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("test_item has %d rows after TruncateDBTables, expected none", count)
+	}
+}
@@ -34,6 +34,14 @@ func PostgresTestDB() TestDB {
 	}
 }
 
+func SQLite3TestDB() TestDB {
+	// To run all tests in a local test database, set ConnStr to "file:oxygen_test.db?cache=shared&mode=rwc"
+	return TestDB{
+		DriverName: "sqlite3",
+		ConnStr:    "file::memory:?cache=shared&mode=memory",
+	}
+}
+
 func SplitHostPortDefault(input, defaultHost, defaultPort string) (NetworkAddress, error) {
 	addr := NetworkAddress{
 		Host: defaultHost,
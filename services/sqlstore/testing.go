@@ -0,0 +1,37 @@
+package sqlstore
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Suj8K/oxygen-go/bus"
+	"github.com/Suj8K/oxygen-go/services/sqlstore/migrator"
+	"github.com/Suj8K/oxygen-go/setting"
+)
+
+// InitTestDB returns a store on a SQLite database in a temporary directory of the test, with the migrations
+// and the scoped migrations applied, so that tests do not need the docker-compose Postgres.
+func InitTestDB(t testing.TB, migrations DatabaseMigrator, scoped ...ScopedMigrations) *SQLStore {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	sec := cfg.Raw.Section("database")
+	sec.Key("type").SetValue(migrator.SQLite)
+	sec.Key("path").SetValue(filepath.Join(t.TempDir(), "oxygen.db"))
+
+	ss, err := ProvideService(cfg, migrations, bus.ProvideBus(), false)
+	if err != nil {
+		t.Fatalf("failed to open the test database: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = ss.engine.Close()
+	})
+
+	for _, m := range scoped {
+		ss.RegisterMigrations(m)
+	}
+	if err := ss.RunMigrations(false); err != nil {
+		t.Fatalf("failed to migrate the test database: %v", err)
+	}
+	return ss
+}
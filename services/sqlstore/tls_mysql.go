@@ -0,0 +1,42 @@
+package sqlstore
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// makeCert builds the TLS configuration registered with the mysql driver from the ssl settings of the database config.
+func makeCert(config DatabaseConfig) (*tls.Config, error) {
+	rootCertPool := x509.NewCertPool()
+	pem, err := os.ReadFile(config.CaCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read DB CA Cert path %q: %w", config.CaCertPath, err)
+	}
+	if ok := rootCertPool.AppendCertsFromPEM(pem); !ok {
+		return nil, fmt.Errorf("failed to append CA certificate from %q", config.CaCertPath)
+	}
+
+	tlsConfig := &tls.Config{
+		RootCAs: rootCertPool,
+	}
+	if config.ClientCertPath != "" && config.ClientKeyPath != "" {
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(config.ClientCertPath, config.ClientKeyPath)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		}
+	}
+	tlsConfig.ServerName = config.ServerCertName
+	if config.SslMode == "skip-verify" {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	// Return more meaningful error before it is too late
+	if config.ServerCertName == "" && !tlsConfig.InsecureSkipVerify {
+		return nil, fmt.Errorf("server_cert_name is missing. Consider using ssl_mode = skip-verify")
+	}
+	return tlsConfig, nil
+}
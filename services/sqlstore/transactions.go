@@ -1 +1,97 @@
 package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Suj8K/oxygen-go/bus"
+)
+
+// WithTransactionalDbSession calls the callback with a session within a transaction.
+// If a transaction has been started by sqlstore.InTransaction() with the same context the callback joins it,
+// otherwise a new transaction is started and committed (or rolled back on error) when the callback returns.
+// A new transaction failing with a deadlock, serialization or database locked error is rolled back and
+// the callback is called again, at most DatabaseConfig.TransactionRetries times.
+func (ss *SQLStore) WithTransactionalDbSession(ctx context.Context, callback DBTransactionFunc) error {
+	return ss.inTransactionWithRetry(ctx, callback)
+}
+
+// InTransaction starts a transaction and calls the fn.
+// It stores the session in the context so that every WithDbSession and WithTransactionalDbSession call
+// made with the context passed to fn runs inside the same transaction.
+// fn is called again if the transaction is retried, so it must not have side effects outside of the database.
+func (ss *SQLStore) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return ss.inTransactionWithRetry(ctx, func(sess *DBSession) error {
+		withValue := context.WithValue(ctx, ContextSessionKey{}, sess)
+		return fn(withValue)
+	})
+}
+
+func (ss *SQLStore) inTransactionWithRetry(ctx context.Context, callback DBTransactionFunc) error {
+	if _, ok := ctx.Value(ContextSessionKey{}).(*DBSession); ok {
+		// the transaction belongs to an outer scope, which is the one retrying it
+		return ss.inTransactionCtx(ctx, callback)
+	}
+
+	return ss.retryOnLocks(ctx, ss.dbCfg.TransactionRetries, &ss.retries.transactions, func() error {
+		return ss.inTransactionCtx(ctx, callback)
+	})
+}
+
+func (ss *SQLStore) inTransactionCtx(ctx context.Context, callback DBTransactionFunc) error {
+	sess, isNew, err := startSessionOrUseExisting(ctx, ss.engine, true)
+	if err != nil {
+		return err
+	}
+
+	if !sess.transactionOpen && !isNew {
+		// this should not happen because the only place that creates reusable session begins a new transaction.
+		return fmt.Errorf("cannot reuse existing session that did not start transaction")
+	}
+
+	if isNew { // if this call initiated the session, it should be responsible for closing it.
+		defer sess.Close()
+	}
+
+	err = callback(sess)
+
+	// the outbox rows are written by the outermost scope only, once all the events have been queued
+	if err == nil && isNew && ss.cfg.EventOutboxEnabled {
+		err = writeOutbox(sess, sess.events)
+	}
+
+	if !isNew {
+		// Do not commit the transaction if the session was reused,
+		// the outer scope commits or rolls back once it is done.
+		return err
+	}
+
+	if err != nil {
+		// the events queued by the callback are discarded together with the session
+		if rollErr := sess.Rollback(); rollErr != nil {
+			return fmt.Errorf("rolling back transaction due to error failed: %s: %w", rollErr, err)
+		}
+		return err
+	}
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+
+	publishAfterCommit(ctx, ss.bus, sess.events)
+
+	return nil
+}
+
+// publishAfterCommit delivers the events queued with DBSession.PublishAfterCommit,
+// the transaction is already committed so a failing handler is only logged.
+func publishAfterCommit(ctx context.Context, bus bus.Bus, events []interface{}) {
+	if bus == nil {
+		return
+	}
+	for _, e := range events {
+		if err := bus.Publish(ctx, e); err != nil {
+			log.Printf("failed to publish event %T after commit: %v", e, err)
+		}
+	}
+}
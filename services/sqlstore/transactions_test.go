@@ -0,0 +1,86 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestInTransaction(t *testing.T) {
+	ss := InitTestDB(t, &testMigrations{})
+	ctx := context.Background()
+
+	insert := func(ctx context.Context, name string) error {
+		return ss.WithDbSession(ctx, func(sess *DBSession) error {
+			_, err := sess.Exec("INSERT INTO test_item (name, value) VALUES (?, 0)", name)
+			return err
+		})
+	}
+	count := func() int64 {
+		t.Helper()
+		n, err := ss.GetEngine().Table("test_item").Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	t.Run("nested sessions reuse the transaction", func(t *testing.T) {
+		err := ss.InTransaction(ctx, func(ctx context.Context) error {
+			var outer, inner *DBSession
+			if err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+				outer = sess
+				return nil
+			}); err != nil {
+				return err
+			}
+			if err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+				inner = sess
+				return nil
+			}); err != nil {
+				return err
+			}
+			if outer == nil || outer != inner {
+				t.Errorf("the nested calls got the sessions %p and %p, expected the one of the transaction", outer, inner)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("an error rolls back every write", func(t *testing.T) {
+		failed := errors.New("failed")
+		err := ss.InTransaction(ctx, func(ctx context.Context) error {
+			if err := insert(ctx, "a"); err != nil {
+				return err
+			}
+			if err := insert(ctx, "b"); err != nil {
+				return err
+			}
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Fatalf("InTransaction returned %v, expected the error of fn", err)
+		}
+		if n := count(); n != 0 {
+			t.Errorf("test_item has %d rows after the rollback, expected none", n)
+		}
+	})
+
+	t.Run("the writes are committed together", func(t *testing.T) {
+		err := ss.InTransaction(ctx, func(ctx context.Context) error {
+			if err := insert(ctx, "a"); err != nil {
+				return err
+			}
+			return insert(ctx, "b")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := count(); n != 2 {
+			t.Errorf("test_item has %d rows after the commit, expected 2", n)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package user
+
+import (
+	"time"
+
+	"github.com/Suj8K/oxygen-go/util"
+)
+
+// UserDTO is the public representation of a user. API responses use it, or another DTO, instead of User,
+// which holds the password, salt and rands.
+type UserDTO struct {
+	ID               int64      `json:"id"`
+	Email            string     `json:"email"`
+	Name             string     `json:"name"`
+	Login            string     `json:"login"`
+	Company          string     `json:"company"`
+	Theme            string     `json:"theme"`
+	OrgID            int64      `json:"orgId"`
+	IsGrafanaAdmin   bool       `json:"isGrafanaAdmin"`
+	IsDisabled       bool       `json:"isDisabled"`
+	IsServiceAccount bool       `json:"isServiceAccount"`
+	EmailVerified    bool       `json:"emailVerified"`
+	HelpFlags1       HelpFlags1 `json:"helpFlags1"`
+	LastSeenAt       time.Time  `json:"lastSeenAt"`
+	LastSeenAtAge    string     `json:"lastSeenAtAge"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+	CreatedAt        time.Time  `json:"createdAt"`
+}
+
+// NewUserDTO maps a user to its public representation.
+func NewUserDTO(u *User) *UserDTO {
+	return &UserDTO{
+		ID:               u.ID,
+		Email:            u.Email,
+		Name:             u.Name,
+		Login:            u.Login,
+		Company:          u.Company,
+		Theme:            u.Theme,
+		OrgID:            u.AccountId,
+		IsGrafanaAdmin:   u.IsAdmin,
+		IsDisabled:       u.IsDisabled,
+		IsServiceAccount: u.IsServiceAccount,
+		EmailVerified:    u.EmailVerified,
+		HelpFlags1:       u.HelpFlags1,
+		LastSeenAt:       u.LastSeenAt,
+		LastSeenAtAge:    util.GetAgeString(u.LastSeenAt),
+		UpdatedAt:        u.Updated,
+		CreatedAt:        u.Created,
+	}
+}
+
+// NewUserProfileDTO maps a user to its profile.
+func NewUserProfileDTO(u *User) *UserProfileDTO {
+	return &UserProfileDTO{
+		ID:             u.ID,
+		Name:           u.Name,
+		Email:          u.Email,
+		Login:          u.Login,
+		Theme:          u.Theme,
+		OrgID:          u.AccountId,
+		IsGrafanaAdmin: u.IsAdmin,
+		IsDisabled:     u.IsDisabled,
+		UpdatedAt:      u.Updated,
+		CreatedAt:      u.Created,
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/Suj8K/oxygen-go/services/user"
 	"github.com/Suj8K/oxygen-go/util"
 	"log"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -24,14 +23,15 @@ type store interface {
 	CaseInsensitiveLoginConflict(context.Context, string, string) error
 	GetByLogin(context.Context, *user.GetUserByLoginQuery) (*user.User, error)
 	GetByEmail(context.Context, *user.GetUserByEmailQuery) (*user.User, error)
-	Update(context.Context, *user.UpdateUserCommand) error
+	// Update, SetHelpFlag and UpdatePermissions return the number of users updated, none when the user does not exist
+	Update(context.Context, *user.UpdateUserCommand) (int64, error)
 	ChangePassword(context.Context, *user.ChangeUserPasswordCommand) error
 	UpdateLastSeenAt(context.Context, *user.UpdateUserLastSeenAtCommand) error
 	GetSignedInUser(context.Context, *user.GetSignedInUserQuery) (*user.SignedInUser, error)
 	UpdateUser(context.Context, *user.User) error
 	GetProfile(context.Context, *user.GetUserProfileQuery) (*user.UserProfileDTO, error)
-	SetHelpFlag(context.Context, *user.SetUserHelpFlagCommand) error
-	UpdatePermissions(context.Context, int64, bool) error
+	SetHelpFlag(context.Context, *user.SetUserHelpFlagCommand) (int64, error)
+	UpdatePermissions(context.Context, int64, bool) (int64, error)
 	BatchDisableUsers(context.Context, *user.BatchDisableUsersCommand) error
 	Disable(context.Context, *user.DisableUserCommand) error
 	Search(context.Context, *user.SearchUsersQuery) (*user.SearchUserQueryResult, error)
@@ -56,7 +56,7 @@ func ProvideStore(db db.DB) sqlStore {
 
 func (ss *sqlStore) Insert(ctx context.Context, cmd *user.User) (int64, error) {
 	var err error
-	err = ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+	err = ss.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
 		sess.UseBool("is_admin")
 
 		if _, err = sess.Insert(cmd); err != nil {
@@ -156,7 +156,7 @@ func (ss *sqlStore) GetByID(ctx context.Context, userID int64) (*user.User, erro
 }
 
 func (ss *sqlStore) notServiceAccountFilter() string {
-	return fmt.Sprintf("%s.is_admin = %s",
+	return fmt.Sprintf("%s.is_service_account = %s",
 		ss.dialect.Quote("user"),
 		ss.dialect.BooleanStr(false))
 }
@@ -315,13 +315,14 @@ func (ss *sqlStore) loginConflict(ctx context.Context, sess *db.Session, login,
 	return nil
 }
 
-func (ss *sqlStore) Update(ctx context.Context, cmd *user.UpdateUserCommand) error {
+func (ss *sqlStore) Update(ctx context.Context, cmd *user.UpdateUserCommand) (int64, error) {
 	if ss.caseInsensitiveLogin {
 		cmd.Login = strings.ToLower(cmd.Login)
 		cmd.Email = strings.ToLower(cmd.Email)
 	}
 
-	return ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+	var rows int64
+	err := ss.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
 		user := user.User{
 			Name:    cmd.Name,
 			Email:   cmd.Email,
@@ -330,7 +331,8 @@ func (ss *sqlStore) Update(ctx context.Context, cmd *user.UpdateUserCommand) err
 			Updated: time.Now(),
 		}
 
-		if _, err := sess.ID(cmd.UserID).Where(ss.notServiceAccountFilter()).Update(&user); err != nil {
+		var err error
+		if rows, err = sess.ID(cmd.UserID).Where(ss.notServiceAccountFilter()).Update(&user); err != nil || rows == 0 {
 			return err
 		}
 
@@ -341,8 +343,8 @@ func (ss *sqlStore) Update(ctx context.Context, cmd *user.UpdateUserCommand) err
 		}
 
 		sess.PublishAfterCommit(&events.UserUpdated{
-			Timestamp: user.Created,
-			Id:        user.ID,
+			Timestamp: user.Updated,
+			Id:        cmd.UserID,
 			Name:      user.Name,
 			Login:     user.Login,
 			Email:     user.Email,
@@ -350,6 +352,7 @@ func (ss *sqlStore) Update(ctx context.Context, cmd *user.UpdateUserCommand) err
 
 		return nil
 	})
+	return rows, err
 }
 
 func (ss *sqlStore) ChangePassword(ctx context.Context, cmd *user.ChangeUserPasswordCommand) error {
@@ -379,11 +382,7 @@ func (ss *sqlStore) UpdateLastSeenAt(ctx context.Context, cmd *user.UpdateUserLa
 func (ss *sqlStore) GetSignedInUser(ctx context.Context, query *user.GetSignedInUserQuery) (*user.SignedInUser, error) {
 	var signedInUser user.SignedInUser
 	err := ss.db.WithDbSession(ctx, func(dbSess *db.Session) error {
-		orgId := "u.org_id"
-		if query.OrgID > 0 {
-			orgId = strconv.FormatInt(query.OrgID, 10)
-		}
-
+		// there are no org tables, the account of a user stands for its org
 		var rawSQL = `SELECT
 		u.id                  as user_id,
 		u.is_admin            as is_grafana_admin,
@@ -393,33 +392,40 @@ func (ss *sqlStore) GetSignedInUser(ctx context.Context, query *user.GetSignedIn
 		u.is_disabled         as is_disabled,
 		u.help_flags1         as help_flags1,
 		u.last_seen_at        as last_seen_at,
-		(SELECT COUNT(*) FROM org_user where org_user.user_id = u.id) as org_count,
-		org.name              as org_name,
-		org_user.role         as org_role,
-		org.id                as org_id,
+		u.account_id          as org_id,
 		u.is_service_account  as is_service_account
-		FROM ` + ss.dialect.Quote("user") + ` as u
-		LEFT OUTER JOIN org_user on org_user.org_id = ` + orgId + ` and org_user.user_id = u.id
-		LEFT OUTER JOIN org on org.id = org_user.org_id `
+		FROM ` + ss.dialect.Quote("user") + ` as u `
 
-		sess := dbSess.Table("user")
-		sess = sess.Context(ctx)
+		args := make([]interface{}, 0, 2)
 		switch {
 		case query.UserID > 0:
-			sess.SQL(rawSQL+"WHERE u.id=?", query.UserID)
+			rawSQL += "WHERE u.id=?"
+			args = append(args, query.UserID)
 		case query.Login != "":
 			if ss.caseInsensitiveLogin {
-				sess.SQL(rawSQL+"WHERE LOWER(u.login)=LOWER(?)", query.Login)
+				rawSQL += "WHERE LOWER(u.login)=LOWER(?)"
 			} else {
-				sess.SQL(rawSQL+"WHERE u.login=?", query.Login)
+				rawSQL += "WHERE u.login=?"
 			}
+			args = append(args, query.Login)
 		case query.Email != "":
 			if ss.caseInsensitiveLogin {
-				sess.SQL(rawSQL+"WHERE LOWER(u.email)=LOWER(?)", query.Email)
+				rawSQL += "WHERE LOWER(u.email)=LOWER(?)"
 			} else {
-				sess.SQL(rawSQL+"WHERE u.email=?", query.Email)
+				rawSQL += "WHERE u.email=?"
 			}
+			args = append(args, query.Email)
+		default:
+			return user.ErrUserNotFound
 		}
+		if query.OrgID > 0 {
+			rawSQL += " AND u.account_id=?"
+			args = append(args, query.OrgID)
+		}
+
+		sess := dbSess.Table("user")
+		sess = sess.Context(ctx)
+		sess.SQL(rawSQL, args...)
 		has, err := sess.Get(&signedInUser)
 		if err != nil {
 			return err
@@ -451,55 +457,46 @@ func (ss *sqlStore) GetProfile(ctx context.Context, query *user.GetUserProfileQu
 			return user.ErrUserNotFound
 		}
 
-		userProfile = user.UserProfileDTO{
-			ID:             usr.ID,
-			Name:           usr.Name,
-			Email:          usr.Email,
-			Login:          usr.Login,
-			Theme:          usr.Theme,
-			IsGrafanaAdmin: usr.IsAdmin,
-			IsDisabled:     usr.IsDisabled,
-			UpdatedAt:      usr.Updated,
-			CreatedAt:      usr.Created,
-		}
+		userProfile = *user.NewUserProfileDTO(&usr)
 
 		return err
 	})
 	return &userProfile, err
 }
 
-func (ss *sqlStore) SetHelpFlag(ctx context.Context, cmd *user.SetUserHelpFlagCommand) error {
-	return ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+func (ss *sqlStore) SetHelpFlag(ctx context.Context, cmd *user.SetUserHelpFlagCommand) (int64, error) {
+	var rows int64
+	err := ss.db.WithDbSession(ctx, func(sess *db.Session) error {
 		user := user.User{
-			ID:      cmd.UserID,
-			Updated: time.Now(),
+			ID:         cmd.UserID,
+			HelpFlags1: cmd.HelpFlags1,
+			Updated:    time.Now(),
 		}
 
-		_, err := sess.ID(cmd.UserID).Cols("help_flags1").Update(&user)
+		var err error
+		rows, err = sess.ID(cmd.UserID).Cols("help_flags1").Update(&user)
 		return err
 	})
+	return rows, err
 }
 
 // UpdatePermissions sets the user Server Admin flag
-func (ss *sqlStore) UpdatePermissions(ctx context.Context, userID int64, isAdmin bool) error {
-	return ss.db.WithDbSession(ctx, func(sess *db.Session) error {
-		var user user.User
-		if _, err := sess.ID(userID).Where(ss.notServiceAccountFilter()).Get(&user); err != nil {
-			return err
+func (ss *sqlStore) UpdatePermissions(ctx context.Context, userID int64, isAdmin bool) (int64, error) {
+	var rows int64
+	err := ss.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		user := user.User{
+			IsAdmin: isAdmin,
+			Updated: time.Now(),
 		}
 
-		user.IsAdmin = isAdmin
-		sess.UseBool("is_admin")
-		_, err := sess.ID(user.ID).Update(&user)
-		if err != nil {
+		var err error
+		if rows, err = sess.ID(userID).Where(ss.notServiceAccountFilter()).Cols("is_admin").Update(&user); err != nil || rows == 0 {
 			return err
 		}
 		// validate that after update there is at least one server admin
-		if err := validateOneAdminLeft(ctx, sess); err != nil {
-			return err
-		}
-		return nil
+		return validateOneAdminLeft(ctx, sess)
 	})
+	return rows, err
 }
 
 func (ss *sqlStore) Count(ctx context.Context) (int64, error) {
@@ -594,7 +591,7 @@ func (ss *sqlStore) Search(ctx context.Context, query *user.SearchUsersQuery) (*
 		joinCondition = "user_auth.id=" + joinCondition + ss.dialect.Limit(1) + ")"
 		sess.Join("LEFT", "user_auth", joinCondition)
 		if query.OrgID > 0 {
-			whereConditions = append(whereConditions, "org_id = ?")
+			whereConditions = append(whereConditions, "u.account_id = ?")
 			whereParams = append(whereParams, query.OrgID)
 		}
 
@@ -12,6 +12,7 @@ import (
 )
 
 type Service struct {
+	db                   db.DB
 	store                store
 	caseInsensitiveLogin bool
 }
@@ -21,6 +22,7 @@ func ProvideService(
 ) (user.Service, error) {
 	store := ProvideStore(db)
 	s := &Service{
+		db:    db,
 		store: &store,
 	}
 
@@ -39,11 +41,6 @@ func (s *Service) GetUsageStats(ctx context.Context) map[string]interface{} {
 }
 
 func (s *Service) Create(ctx context.Context, cmd *user.CreateUserCommand) (*user.User, error) {
-	err := s.store.LoginConflict(ctx, cmd.Login, cmd.Email, s.caseInsensitiveLogin)
-	if err != nil {
-		return nil, user.ErrUserAlreadyExists
-	}
-
 	// create user
 	usr := &user.User{
 		Email:            cmd.Email,
@@ -78,7 +75,16 @@ func (s *Service) Create(ctx context.Context, cmd *user.CreateUserCommand) (*use
 		usr.Password = encodedPassword
 	}
 
-	_, err = s.store.Insert(ctx, usr)
+	// the conflict check and the insert share one transaction so that a concurrent
+	// create with the same login or email cannot slip in between them
+	err = s.db.InTransaction(ctx, func(ctx context.Context) error {
+		if err := s.store.LoginConflict(ctx, cmd.Login, cmd.Email, s.caseInsensitiveLogin); err != nil {
+			return user.ErrUserAlreadyExists
+		}
+
+		_, err := s.store.Insert(ctx, usr)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -87,12 +93,14 @@ func (s *Service) Create(ctx context.Context, cmd *user.CreateUserCommand) (*use
 }
 
 func (s *Service) Delete(ctx context.Context, cmd *user.DeleteUserCommand) error {
-	_, err := s.store.GetNotServiceAccount(ctx, cmd.UserID)
-	if err != nil {
-		return err
-	}
-	// delete from all the stores
-	return s.store.Delete(ctx, cmd.UserID)
+	return s.db.InTransaction(ctx, func(ctx context.Context) error {
+		_, err := s.store.GetNotServiceAccount(ctx, cmd.UserID)
+		if err != nil {
+			return err
+		}
+		// delete from all the stores
+		return s.store.Delete(ctx, cmd.UserID)
+	})
 }
 
 func (s *Service) GetByID(ctx context.Context, query *user.GetUserByIDQuery) (*user.User, error) {
@@ -122,11 +130,34 @@ func (s *Service) Update(ctx context.Context, cmd *user.UpdateUserCommand) error
 		cmd.Login = strings.ToLower(cmd.Login)
 		cmd.Email = strings.ToLower(cmd.Email)
 	}
-	return s.store.Update(ctx, cmd)
+	return updated(s.store.Update(ctx, cmd))
 }
 
+// ChangePassword checks the old password of the user and saves the hash of the new one.
 func (s *Service) ChangePassword(ctx context.Context, cmd *user.ChangeUserPasswordCommand) error {
-	return s.store.ChangePassword(ctx, cmd)
+	return s.db.InTransaction(ctx, func(ctx context.Context) error {
+		usr, err := s.store.GetByID(ctx, cmd.UserID)
+		if err != nil {
+			return err
+		}
+		if usr.Password == "" {
+			return user.ErrPasswordNotSet
+		}
+		valid, err := usr.ValidatePassword(cmd.OldPassword)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return user.ErrInvalidPassword
+		}
+
+		encoded, err := util.EncodePassword(cmd.NewPassword, usr.Salt)
+		if err != nil {
+			return err
+		}
+		// the store saves the password as given
+		return s.store.ChangePassword(ctx, &user.ChangeUserPasswordCommand{UserID: cmd.UserID, NewPassword: encoded})
+	})
 }
 
 func (s *Service) UpdateLastSeenAt(ctx context.Context, cmd *user.UpdateUserLastSeenAtCommand) error {
@@ -150,11 +181,22 @@ func (s *Service) BatchDisableUsers(ctx context.Context, cmd *user.BatchDisableU
 }
 
 func (s *Service) UpdatePermissions(ctx context.Context, userID int64, isAdmin bool) error {
-	return s.store.UpdatePermissions(ctx, userID, isAdmin)
+	return updated(s.store.UpdatePermissions(ctx, userID, isAdmin))
 }
 
 func (s *Service) SetUserHelpFlag(ctx context.Context, cmd *user.SetUserHelpFlagCommand) error {
-	return s.store.SetHelpFlag(ctx, cmd)
+	return updated(s.store.SetHelpFlag(ctx, cmd))
+}
+
+// updated returns ErrUserNotFound when an update of the store matched no user, e.g. one deleted meanwhile.
+func updated(rows int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return user.ErrUserNotFound
+	}
+	return nil
 }
 
 func (s *Service) GetProfile(ctx context.Context, query *user.GetUserProfileQuery) (*user.UserProfileDTO, error) {
@@ -165,10 +207,6 @@ func (s *Service) GetProfile(ctx context.Context, query *user.GetUserProfileQuer
 // CreateServiceAccount creates a service account in the user table and adds service account to an organisation in the org_user table
 func (s *Service) CreateServiceAccount(ctx context.Context, cmd *user.CreateUserCommand) (*user.User, error) {
 	cmd.Email = cmd.Login
-	err := s.store.LoginConflict(ctx, cmd.Login, cmd.Email, s.caseInsensitiveLogin)
-	if err != nil {
-		return nil, fmt.Errorf("service account with login %s already exists", cmd.Login)
-	}
 
 	// create user
 	usr := &user.User{
@@ -193,7 +231,14 @@ func (s *Service) CreateServiceAccount(ctx context.Context, cmd *user.CreateUser
 	}
 	usr.Rands = rands
 
-	_, err = s.store.Insert(ctx, usr)
+	err = s.db.InTransaction(ctx, func(ctx context.Context) error {
+		if err := s.store.LoginConflict(ctx, cmd.Login, cmd.Email, s.caseInsensitiveLogin); err != nil {
+			return fmt.Errorf("service account with login %s: %w", cmd.Login, user.ErrUserAlreadyExists)
+		}
+
+		_, err := s.store.Insert(ctx, usr)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -1,10 +1,13 @@
 package user
 
 import (
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/Suj8K/oxygen-go/util"
 )
 
 type HelpFlags1 uint64
@@ -25,28 +28,31 @@ var (
 	ErrLastGrafanaAdmin  = errors.New("cannot remove last grafana admin")
 	ErrProtectedUser     = errors.New("cannot adopt protected user")
 	ErrNoUniqueID        = errors.New("identifying id not found")
+	ErrPasswordNotSet    = errors.New("user has no password")
+	ErrInvalidPassword   = errors.New("invalid password")
 )
 
 type User struct {
-	ID               int64  `xorm:"pk autoincr 'id'"`
-	Version          int    `json:"version" xorm:"version"`
-	Email            string `json:"email" xorm:"email"`
-	Name             string `json:"name" xorm:"name"`
-	Login            string `json:"login" xorm:"login"`
-	Password         string `json:"password" xorm:"password"`
-	Salt             string `json:"salt" xorm:"salt"`
-	Rands            string `json:"rands" xorm:"rands"`
-	Company          string `json:"company" xorm:"company"`
-	EmailVerified    bool   `json:"email_verified" xorm:"-"`
-	Theme            string `json:"theme" xorm:"-"`
-	IsDisabled       bool   `json:"is_disabled" xorm:"-"`
-	AccountId        int64  `json:"account_id" xorm:"account_id"`
-	IsAdmin          bool   `json:"is_admin" xorm:"is_admin"`
-	IsServiceAccount bool   `json:"is_service_account" xorm:"-"`
+	ID               int64      `xorm:"pk autoincr 'id'"`
+	Version          int        `json:"version" xorm:"'version'"`
+	Email            string     `json:"email" xorm:"email"`
+	Name             string     `json:"name" xorm:"name"`
+	Login            string     `json:"login" xorm:"login"`
+	Password         string     `json:"-" xorm:"password"`
+	Salt             string     `json:"-" xorm:"salt"`
+	Rands            string     `json:"-" xorm:"rands"`
+	Company          string     `json:"company" xorm:"company"`
+	EmailVerified    bool       `json:"email_verified" xorm:"email_verified"`
+	Theme            string     `json:"theme" xorm:"theme"`
+	HelpFlags1       HelpFlags1 `json:"help_flags1" xorm:"'help_flags1' bigint"`
+	IsDisabled       bool       `json:"is_disabled" xorm:"is_disabled"`
+	AccountId        int64      `json:"account_id" xorm:"account_id"`
+	IsAdmin          bool       `json:"is_admin" xorm:"is_admin"`
+	IsServiceAccount bool       `json:"is_service_account" xorm:"is_service_account"`
 
 	Created    time.Time `json:"created" xorm:"created"`
 	Updated    time.Time `json:"updated" xorm:"updated"`
-	LastSeenAt time.Time `json:"last_seen_at" xorm:"-"`
+	LastSeenAt time.Time `json:"last_seen_at" xorm:"last_seen_at"`
 }
 
 type CreateUserCommand struct {
@@ -224,6 +230,19 @@ func (u *User) NameOrFallback() string {
 	return u.Email
 }
 
+// ValidatePassword compares the PBKDF2 hash of password with the one of the user in constant time.
+// A user without password never matches.
+func (u *User) ValidatePassword(password string) (bool, error) {
+	if u.Password == "" {
+		return false, nil
+	}
+	encoded, err := util.EncodePassword(password, u.Salt)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare([]byte(encoded), []byte(u.Password)) == 1, nil
+}
+
 type DeleteUserCommand struct {
 	UserID int64
 }
@@ -0,0 +1,194 @@
+package setting
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding ini keys,
+// e.g. OXYGEN_DATABASE_HOST overrides the host key of the [database] section.
+const EnvPrefix = "OXYGEN"
+
+const (
+	defaultsIniPath = "conf/defaults.ini"
+	customIniPath   = "conf/custom.ini"
+)
+
+type Cfg struct {
+	Raw *ini.File
+
+	// Paths
+	HomePath string
+	DataPath string
+
+	// HTTP Server Settings
+	HTTPAddr string
+	HTTPPort string
+
+	// Event outbox
+	EventOutboxEnabled        bool
+	EventOutboxPollInterval   time.Duration
+	EventOutboxBatchSize      int
+	EventOutboxMaxBackoff     time.Duration
+	EventOutboxRetention      time.Duration
+	EventOutboxWebhookURL     string
+	EventOutboxWebhookTimeout time.Duration
+
+	// Auth
+	LoginMaxLifetime time.Duration
+}
+
+type CommandLineArgs struct {
+	Config   string
+	HomePath string
+}
+
+func NewCfg() *Cfg {
+	return &Cfg{
+		Raw: ini.Empty(),
+	}
+}
+
+// Load reads the defaults ini, the custom ini given by args.Config (or conf/custom.ini if present)
+// and applies the environment variable overrides on top of them.
+func (cfg *Cfg) Load(args CommandLineArgs) error {
+	homePath, err := resolveHomePath(args.HomePath)
+	if err != nil {
+		return err
+	}
+	cfg.HomePath = homePath
+
+	iniFile, err := cfg.loadConfiguration(args.Config)
+	if err != nil {
+		return err
+	}
+	cfg.Raw = iniFile
+
+	paths := iniFile.Section("paths")
+	cfg.DataPath = makeAbsolute(paths.Key("data").MustString("data"), cfg.HomePath)
+
+	server := iniFile.Section("server")
+	cfg.HTTPAddr = server.Key("http_addr").String()
+	cfg.HTTPPort = server.Key("http_port").MustString("9096")
+
+	outbox := iniFile.Section("event_outbox")
+	cfg.EventOutboxEnabled = outbox.Key("enabled").MustBool(false)
+	cfg.EventOutboxPollInterval = outbox.Key("poll_interval").MustDuration(5 * time.Second)
+	cfg.EventOutboxBatchSize = outbox.Key("batch_size").MustInt(100)
+	cfg.EventOutboxMaxBackoff = outbox.Key("max_backoff").MustDuration(5 * time.Minute)
+	if cfg.EventOutboxPollInterval <= 0 {
+		return fmt.Errorf("poll_interval of [event_outbox] must be positive, got %s", cfg.EventOutboxPollInterval)
+	}
+	if cfg.EventOutboxBatchSize <= 0 {
+		return fmt.Errorf("batch_size of [event_outbox] must be positive, got %d", cfg.EventOutboxBatchSize)
+	}
+	cfg.EventOutboxRetention = outbox.Key("delivered_retention").MustDuration(7 * 24 * time.Hour)
+	if cfg.EventOutboxRetention < 0 {
+		return fmt.Errorf("delivered_retention of [event_outbox] must not be negative, got %s", cfg.EventOutboxRetention)
+	}
+	cfg.EventOutboxWebhookURL = outbox.Key("webhook_url").String()
+	cfg.EventOutboxWebhookTimeout = outbox.Key("webhook_timeout").MustDuration(10 * time.Second)
+	if cfg.EventOutboxEnabled && cfg.EventOutboxWebhookURL == "" {
+		// the events would pile up in the outbox without being delivered
+		return fmt.Errorf("[event_outbox] is enabled without a sink, webhook_url has to be set")
+	}
+
+	auth := iniFile.Section("auth")
+	cfg.LoginMaxLifetime = auth.Key("login_maximum_lifetime").MustDuration(30 * 24 * time.Hour)
+
+	return nil
+}
+
+// ListenAddr returns the address the HTTP server should listen on.
+func (cfg *Cfg) ListenAddr() string {
+	return cfg.HTTPAddr + ":" + cfg.HTTPPort
+}
+
+func (cfg *Cfg) loadConfiguration(customConfigFile string) (*ini.File, error) {
+	defaultConfigFile := filepath.Join(cfg.HomePath, defaultsIniPath)
+	if _, err := os.Stat(defaultConfigFile); err != nil {
+		return nil, fmt.Errorf("could not find config defaults, make sure homepath command line parameter is set or working directory is homepath: %w", err)
+	}
+
+	parsedFile, err := ini.Load(defaultConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse defaults.ini: %w", err)
+	}
+
+	if customConfigFile == "" {
+		customConfigFile = filepath.Join(cfg.HomePath, customIniPath)
+		if _, err := os.Stat(customConfigFile); os.IsNotExist(err) {
+			customConfigFile = ""
+		}
+	}
+	if customConfigFile != "" {
+		if err := parsedFile.Append(makeAbsolute(customConfigFile, cfg.HomePath)); err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", customConfigFile, err)
+		}
+	}
+
+	applyEnvVariableOverrides(parsedFile)
+
+	return parsedFile, nil
+}
+
+// applyEnvVariableOverrides replaces the value of every key that has a matching environment variable.
+func applyEnvVariableOverrides(file *ini.File) {
+	for _, section := range file.Sections() {
+		for _, key := range section.Keys() {
+			if envValue := os.Getenv(EnvKey(section.Name(), key.Name())); envValue != "" {
+				key.SetValue(envValue)
+			}
+		}
+	}
+}
+
+// EnvKey returns the environment variable name overriding the given key of a section.
+func EnvKey(sectionName string, keyName string) string {
+	sN := strings.ToUpper(strings.ReplaceAll(sectionName, ".", "_"))
+	sN = strings.ReplaceAll(sN, "-", "_")
+	kN := strings.ToUpper(strings.ReplaceAll(keyName, ".", "_"))
+	return fmt.Sprintf("%s_%s_%s", EnvPrefix, sN, kN)
+}
+
+func resolveHomePath(homePath string) (string, error) {
+	if homePath != "" {
+		return filepath.Abs(homePath)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if pathExists(filepath.Join(wd, defaultsIniPath)) {
+		return wd, nil
+	}
+
+	// the binary is usually built into <homepath>/bin
+	exe, err := os.Executable()
+	if err != nil {
+		return wd, nil
+	}
+	if parent := filepath.Dir(filepath.Dir(exe)); pathExists(filepath.Join(parent, defaultsIniPath)) {
+		return parent, nil
+	}
+
+	return wd, nil
+}
+
+func makeAbsolute(path string, root string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(root, path)
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}